@@ -0,0 +1,161 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+	"github.com/rafaelperezbeato/agentmetrics/internal/collector"
+)
+
+// eventCounters accumulates alert and security event totals across scrapes
+type eventCounters struct {
+	mu        sync.Mutex
+	alerts    map[string]int
+	security  map[string]int
+	watermark collector.Watermark
+}
+
+func newEventCounters() *eventCounters {
+	c := &eventCounters{
+		alerts:   map[string]int{},
+		security: map[string]int{},
+	}
+	// Pre-seed known label values so series exist before the first event
+	for _, lvl := range []agent.AlertLevel{agent.AlertWarning, agent.AlertCritical} {
+		c.alerts[string(lvl)] = 0
+	}
+	for _, sev := range []agent.SecuritySeverity{agent.SecSevLow, agent.SecSevMedium, agent.SecSevHigh, agent.SecSevCritical} {
+		c.security[string(sev)] = 0
+	}
+	return c
+}
+
+// observe counts events that appeared since the previous call
+func (c *eventCounters) observe(st collector.State) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	alerts, events := c.watermark.NewEvents(st)
+	for _, al := range alerts {
+		c.alerts[string(al.Level)]++
+	}
+	for _, evt := range events {
+		c.security[string(evt.Severity)]++
+	}
+}
+
+// handleMetrics serves the Prometheus text exposition format
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	st, err := s.source.State()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	s.counters.observe(st)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	writeMetrics(w, st, s.counters)
+}
+
+// agentGauge describes one per-agent series
+type agentGauge struct {
+	name  string
+	kind  string
+	help  string
+	value func(a agent.Instance) float64
+}
+
+var agentGauges = []agentGauge{
+	{"agentmetrics_agent_cpu_percent", "gauge", "Agent process CPU usage in percent.",
+		func(a agent.Instance) float64 { return a.CPU }},
+	{"agentmetrics_agent_memory_megabytes", "gauge", "Agent process memory usage in MB.",
+		func(a agent.Instance) float64 { return a.Memory }},
+	{"agentmetrics_agent_tokens_total", "counter", "Total tokens consumed by the agent.",
+		func(a agent.Instance) float64 { return float64(a.Tokens.TotalTokens) }},
+	{"agentmetrics_agent_cost_usd", "gauge", "Estimated cost of the agent session in USD.",
+		func(a agent.Instance) float64 { return a.Tokens.EstCost }},
+	{"agentmetrics_agent_requests_total", "counter", "API requests made by the agent.",
+		func(a agent.Instance) float64 { return float64(a.Tokens.RequestCount) }},
+	{"agentmetrics_agent_uptime_seconds", "gauge", "Agent session uptime in seconds.",
+		func(a agent.Instance) float64 { return a.Session.Uptime.Seconds() }},
+}
+
+// writeMetrics renders state and counters as Prometheus text
+func writeMetrics(w io.Writer, st collector.State, counters *eventCounters) {
+	fmt.Fprintln(w, "# HELP agentmetrics_agents Number of detected agents.")
+	fmt.Fprintln(w, "# TYPE agentmetrics_agents gauge")
+	fmt.Fprintf(w, "agentmetrics_agents %d\n", len(st.Agents))
+
+	for _, g := range agentGauges {
+		fmt.Fprintf(w, "# HELP %s %s\n", g.name, g.help)
+		fmt.Fprintf(w, "# TYPE %s %s\n", g.name, g.kind)
+		for _, a := range st.Agents {
+			fmt.Fprintf(w, "%s{%s} %s\n", g.name, agentLabels(a), formatFloat(g.value(a)))
+		}
+	}
+
+	counters.mu.Lock()
+	defer counters.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP agentmetrics_alerts_total Alerts raised since the exporter started, by level.")
+	fmt.Fprintln(w, "# TYPE agentmetrics_alerts_total counter")
+	for _, lvl := range sortedKeys(counters.alerts) {
+		fmt.Fprintf(w, "agentmetrics_alerts_total{level=\"%s\"} %d\n", escapeLabel(lvl), counters.alerts[lvl])
+	}
+
+	fmt.Fprintln(w, "# HELP agentmetrics_security_events_total Security events since the exporter started, by severity.")
+	fmt.Fprintln(w, "# TYPE agentmetrics_security_events_total counter")
+	for _, sev := range sortedKeys(counters.security) {
+		fmt.Fprintf(w, "agentmetrics_security_events_total{severity=\"%s\"} %d\n", escapeLabel(sev), counters.security[sev])
+	}
+
+	if len(st.Suppressions) > 0 {
+		fmt.Fprintln(w, "# HELP agentmetrics_security_suppressed_total Security events dropped by each suppression rule.")
+		fmt.Fprintln(w, "# TYPE agentmetrics_security_suppressed_total counter")
+		for _, c := range st.Suppressions {
+			fmt.Fprintf(w, "agentmetrics_security_suppressed_total{rule=\"%s\"} %d\n", escapeLabel(c.Rule), c.Suppressed)
+		}
+	}
+}
+
+// agentLabels builds the label set identifying one agent instance
+func agentLabels(a agent.Instance) string {
+	labels := [][2]string{
+		{"agent_id", a.Info.ID},
+		{"name", a.Info.Name},
+		{"pid", strconv.Itoa(a.PID)},
+		{"model", a.Tokens.LastModel},
+		{"workdir", a.WorkDir},
+	}
+	parts := make([]string, 0, len(labels))
+	for _, l := range labels {
+		parts = append(parts, fmt.Sprintf(`%s="%s"`, l[0], escapeLabel(l[1])))
+	}
+	return strings.Join(parts, ",")
+}
+
+// escapeLabel escapes a label value per the exposition format
+func escapeLabel(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, `"`, `\"`)
+	return strings.ReplaceAll(v, "\n", `\n`)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
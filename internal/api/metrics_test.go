@@ -0,0 +1,71 @@
+package api
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+	"github.com/rafaelperezbeato/agentmetrics/internal/collector"
+)
+
+func TestMetricsEndpointExposesAgentSeries(t *testing.T) {
+	st := collector.State{
+		Agents: []agent.Instance{{
+			PID:     100,
+			Info:    agent.Info{ID: "claude-code", Name: "Claude Code"},
+			CPU:     12.5,
+			WorkDir: `/tmp/"repo"`,
+		}},
+	}
+	st.Agents[0].Tokens.TotalTokens = 1500
+	st.Agents[0].Tokens.LastModel = "claude-sonnet"
+
+	rec := get(t, NewServer(staticSource{state: st}), "/metrics")
+	body := rec.Body.String()
+
+	want := []string{
+		"# TYPE agentmetrics_agent_cpu_percent gauge",
+		`agentmetrics_agent_cpu_percent{agent_id="claude-code",name="Claude Code",pid="100",model="claude-sonnet",workdir="/tmp/\"repo\""} 12.5`,
+		`agentmetrics_agent_tokens_total{agent_id="claude-code",name="Claude Code",pid="100",model="claude-sonnet",workdir="/tmp/\"repo\""} 1500`,
+		"agentmetrics_agents 1",
+	}
+	for _, line := range want {
+		if !strings.Contains(body, line) {
+			t.Fatalf("expected metrics to contain %q, got:\n%s", line, body)
+		}
+	}
+}
+
+func TestEventCountersOnlyCountNewEvents(t *testing.T) {
+	now := time.Now()
+	st := collector.State{
+		Alerts: []agent.Alert{
+			{Timestamp: now, Level: agent.AlertCritical},
+			{Timestamp: now.Add(-time.Second), Level: agent.AlertWarning},
+		},
+		SecurityEvents: []agent.SecurityEvent{
+			{Timestamp: now, Severity: agent.SecSevHigh},
+		},
+	}
+
+	c := newEventCounters()
+	c.observe(st)
+	c.observe(st)
+
+	if got := c.alerts[string(agent.AlertCritical)]; got != 1 {
+		t.Fatalf("expected 1 critical alert, got %d", got)
+	}
+	if got := c.alerts[string(agent.AlertWarning)]; got != 1 {
+		t.Fatalf("expected 1 warning alert, got %d", got)
+	}
+	if got := c.security[string(agent.SecSevHigh)]; got != 1 {
+		t.Fatalf("expected 1 high security event, got %d", got)
+	}
+
+	st.Alerts = append(st.Alerts, agent.Alert{Timestamp: now.Add(time.Second), Level: agent.AlertCritical})
+	c.observe(st)
+	if got := c.alerts[string(agent.AlertCritical)]; got != 2 {
+		t.Fatalf("expected 2 critical alerts after new event, got %d", got)
+	}
+}
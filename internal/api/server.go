@@ -0,0 +1,134 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+	"github.com/rafaelperezbeato/agentmetrics/internal/collector"
+)
+
+// Server exposes collected agent state as JSON over HTTP
+type Server struct {
+	source   collector.Source
+	mux      *http.ServeMux
+	counters *eventCounters
+}
+
+// NewServer creates an API server reading from source. Pass a
+// collector.Cache so requests between refreshes don't rescan.
+func NewServer(source collector.Source) *Server {
+	s := &Server{
+		source:   source,
+		mux:      http.NewServeMux(),
+		counters: newEventCounters(),
+	}
+
+	s.mux.HandleFunc("GET /api/snapshot", s.handleSnapshot)
+	s.mux.HandleFunc("GET /api/agents", s.handleAgents)
+	s.mux.HandleFunc("GET /api/agents/{id}", s.handleAgent)
+	s.mux.HandleFunc("GET /api/alerts", s.handleAlerts)
+	s.mux.HandleFunc("GET /api/security", s.handleSecurity)
+	s.mux.HandleFunc("GET /api/local-models", s.handleLocalModels)
+	s.mux.HandleFunc("GET /metrics", s.handleMetrics)
+
+	return s
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handleSnapshot(w http.ResponseWriter, r *http.Request) {
+	if st, ok := s.state(w); ok {
+		writeJSON(w, http.StatusOK, st)
+	}
+}
+
+func (s *Server) handleAgents(w http.ResponseWriter, r *http.Request) {
+	if st, ok := s.state(w); ok {
+		writeJSON(w, http.StatusOK, nonNil(st.Agents))
+	}
+}
+
+// handleAgent looks an agent up by PID first, then by agent ID
+func (s *Server) handleAgent(w http.ResponseWriter, r *http.Request) {
+	st, ok := s.state(w)
+	if !ok {
+		return
+	}
+
+	id := r.PathValue("id")
+	if a, found := findAgent(st.Agents, id); found {
+		writeJSON(w, http.StatusOK, a)
+		return
+	}
+	writeError(w, http.StatusNotFound, "agent not found: "+id)
+}
+
+func (s *Server) handleAlerts(w http.ResponseWriter, r *http.Request) {
+	if st, ok := s.state(w); ok {
+		writeJSON(w, http.StatusOK, nonNil(st.Alerts))
+	}
+}
+
+func (s *Server) handleSecurity(w http.ResponseWriter, r *http.Request) {
+	if st, ok := s.state(w); ok {
+		writeJSON(w, http.StatusOK, nonNil(st.SecurityEvents))
+	}
+}
+
+func (s *Server) handleLocalModels(w http.ResponseWriter, r *http.Request) {
+	if st, ok := s.state(w); ok {
+		writeJSON(w, http.StatusOK, nonNil(st.LocalModels))
+	}
+}
+
+// state fetches the current state, writing a 503 on failure
+func (s *Server) state(w http.ResponseWriter) (collector.State, bool) {
+	st, err := s.source.State()
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+		return st, false
+	}
+	return st, true
+}
+
+// findAgent matches a PID or an agent ID such as "claude-code"
+func findAgent(agents []agent.Instance, id string) (agent.Instance, bool) {
+	if pid, err := strconv.Atoi(id); err == nil {
+		for _, a := range agents {
+			if a.PID == pid {
+				return a, true
+			}
+		}
+	}
+	for _, a := range agents {
+		if a.Info.ID == id {
+			return a, true
+		}
+	}
+	return agent.Instance{}, false
+}
+
+// nonNil keeps empty lists serialized as [] instead of null
+func nonNil[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
@@ -0,0 +1,96 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+	"github.com/rafaelperezbeato/agentmetrics/internal/collector"
+)
+
+type staticSource struct {
+	state collector.State
+	err   error
+}
+
+func (s staticSource) State() (collector.State, error) {
+	return s.state, s.err
+}
+
+func testState() collector.State {
+	return collector.State{
+		Agents: []agent.Instance{
+			{PID: 100, Info: agent.Info{ID: "claude-code", Name: "Claude Code"}},
+			{PID: 200, Info: agent.Info{ID: "aider", Name: "Aider"}},
+		},
+	}
+}
+
+func get(t *testing.T, h http.Handler, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec
+}
+
+func TestAgentsEndpointListsAgents(t *testing.T) {
+	srv := NewServer(staticSource{state: testState()})
+
+	rec := get(t, srv, "/api/agents")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	var agents []agent.Instance
+	if err := json.Unmarshal(rec.Body.Bytes(), &agents); err != nil {
+		t.Fatalf("decoding body: %v", err)
+	}
+	if len(agents) != 2 {
+		t.Fatalf("expected 2 agents, got %d", len(agents))
+	}
+}
+
+func TestAgentEndpointByPIDAndID(t *testing.T) {
+	srv := NewServer(staticSource{state: testState()})
+
+	cases := map[string]int{"200": 200, "claude-code": 100}
+	for path, wantPID := range cases {
+		rec := get(t, srv, "/api/agents/"+path)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d", path, rec.Code)
+		}
+		var a agent.Instance
+		if err := json.Unmarshal(rec.Body.Bytes(), &a); err != nil {
+			t.Fatalf("%s: decoding body: %v", path, err)
+		}
+		if a.PID != wantPID {
+			t.Fatalf("%s: expected PID %d, got %d", path, wantPID, a.PID)
+		}
+	}
+
+	if rec := get(t, srv, "/api/agents/999"); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown agent, got %d", rec.Code)
+	}
+}
+
+func TestEmptyListsSerializeAsArrays(t *testing.T) {
+	srv := NewServer(staticSource{})
+
+	for _, path := range []string{"/api/alerts", "/api/security", "/api/local-models"} {
+		rec := get(t, srv, path)
+		if body := rec.Body.String(); body != "[]\n" {
+			t.Fatalf("%s: expected empty array, got %q", path, body)
+		}
+	}
+}
+
+func TestSourceErrorReturns503(t *testing.T) {
+	srv := NewServer(staticSource{err: errors.New("scan failed")})
+
+	if rec := get(t, srv, "/api/agents"); rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,308 @@
+package appconfig
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Rafiki81/libagentmetrics/config"
+)
+
+// Config holds the agentmetrics-specific sections of config.json.
+// They live in the same file as the library config, which ignores them.
+type Config struct {
+	Export   ExportConfig   `json:"export"`
+	Budgets  BudgetsConfig  `json:"budgets"`
+	Security SecurityConfig `json:"security"`
+	Audit    AuditConfig    `json:"audit"`
+
+	Notifications NotificationsConfig `json:"notifications"`
+	Hooks         []Hook              `json:"hooks"`
+	TUI           TUIConfig           `json:"tui"`
+}
+
+// ExportConfig extends the library's export section
+type ExportConfig struct {
+	OTLP OTLPConfig `json:"otlp"`
+}
+
+// OTLPConfig configures pushing metrics and logs to an OTLP/HTTP collector
+type OTLPConfig struct {
+	Enabled     bool              `json:"enabled"`
+	Endpoint    string            `json:"endpoint"`
+	Headers     map[string]string `json:"headers"`
+	Interval    Duration          `json:"interval"`
+	Timeout     Duration          `json:"timeout"`
+	ServiceName string            `json:"service_name"`
+}
+
+// BudgetsConfig holds spending caps that span sessions
+type BudgetsConfig struct {
+	Enabled bool     `json:"enabled"`
+	Rules   []Budget `json:"rules"`
+}
+
+// Budget caps spending in USD per calendar day, week and month. A zero
+// cap is not checked. AgentID and Repo narrow the scope; with neither
+// set the budget covers every agent.
+type Budget struct {
+	Name    string  `json:"name"`
+	AgentID string  `json:"agent_id,omitempty"`
+	Repo    string  `json:"repo,omitempty"`
+	Daily   float64 `json:"daily_usd,omitempty"`
+	Weekly  float64 `json:"weekly_usd,omitempty"`
+	Monthly float64 `json:"monthly_usd,omitempty"`
+	// Action is sent to agents in scope once a cap is exceeded:
+	// "" (report only), "stop" (SIGSTOP) or "term" (SIGTERM)
+	Action string `json:"action,omitempty"`
+}
+
+// SecurityConfig extends the library's security section
+type SecurityConfig struct {
+	Enforcement  EnforcementConfig `json:"enforcement"`
+	Suppressions []Suppression     `json:"suppressions"`
+	CustomRules  []SecurityRule    `json:"custom_rules"`
+}
+
+// SecurityRule is a user-defined policy evaluated next to the built-in
+// categories. Target is "command", "file" or "network"; set Regex or Glob.
+// In both, {workdir} stands for the agent's working directory.
+type SecurityRule struct {
+	Name   string `json:"name"`
+	Target string `json:"target"`
+	Regex  string `json:"regex,omitempty"`
+	Glob   string `json:"glob,omitempty"`
+	// Ops limits file rules to these operations, e.g. ["MODIFY", "DELETE"]
+	Ops []string `json:"ops,omitempty"`
+	// Negate fires when the matcher does not match, e.g. connections to
+	// hosts outside an allow-list
+	Negate      bool   `json:"negate,omitempty"`
+	Severity    string `json:"severity"`
+	Description string `json:"description"`
+	Block       bool   `json:"block"`
+}
+
+// Suppression drops matching security events before they reach the TUI,
+// alerts and exporters. Every set field must match; Expires, when set,
+// turns the rule off after that time.
+type Suppression struct {
+	Name     string `json:"name"`
+	Category string `json:"category,omitempty"`
+	// Pattern is a case-insensitive substring of the event description
+	// or detail
+	Pattern string `json:"pattern,omitempty"`
+	AgentID string `json:"agent_id,omitempty"`
+	// WorkDir is a glob matched against the agent's working directory
+	// and its parents
+	WorkDir string `json:"workdir,omitempty"`
+	// Command is a regular expression matched against the event detail
+	Command string    `json:"command,omitempty"`
+	Expires time.Time `json:"expires,omitempty"`
+}
+
+// EnforcementConfig controls what happens to the process behind a blocked
+// CRITICAL event: "off", "pause" (SIGSTOP, then resume or terminate from
+// the TUI) or "kill" (SIGKILL). DryRun only records what would be done.
+type EnforcementConfig struct {
+	Mode   string `json:"mode"`
+	DryRun bool   `json:"dry_run"`
+}
+
+// AuditConfig controls the hash-chained log of alerts and security
+// events. An empty Path means ~/.agentmetrics/audit.jsonl.
+type AuditConfig struct {
+	Enabled bool   `json:"enabled"`
+	Path    string `json:"path,omitempty"`
+}
+
+// NotificationsConfig lists external targets for alerts and security events
+type NotificationsConfig struct {
+	Webhooks []Webhook     `json:"webhooks"`
+	Desktop  DesktopConfig `json:"desktop"`
+}
+
+// DesktopConfig shows events at or above MinLevel (alerts) and
+// MinSeverity (security) as desktop notifications, at most one per agent
+// per Interval. Command replaces gdbus/notify-send with a notify-send
+// compatible program.
+type DesktopConfig struct {
+	Enabled     bool     `json:"enabled"`
+	MinLevel    string   `json:"min_level"`
+	MinSeverity string   `json:"min_severity"`
+	Interval    Duration `json:"interval"`
+	Command     string   `json:"command,omitempty"`
+	// OnClick is a shell command run when a notification is clicked, with
+	// the agent in AGENTMETRICS_AGENT_NAME. Clicks are only reported for
+	// notifications shown over D-Bus.
+	OnClick string `json:"on_click,omitempty"`
+}
+
+// Webhook posts matching events to URL. Template is a text/template over
+// the notification producing the request body (with a json helper for
+// quoting); empty sends the notification as JSON. MinLevel filters alerts
+// (WARNING, CRITICAL) and MinSeverity security events (LOW..CRITICAL).
+type Webhook struct {
+	Name        string            `json:"name"`
+	URL         string            `json:"url"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Template    string            `json:"template,omitempty"`
+	MinLevel    string            `json:"min_level,omitempty"`
+	MinSeverity string            `json:"min_severity,omitempty"`
+	// Agents limits the target to these agent IDs
+	Agents  []string `json:"agents,omitempty"`
+	Retries int      `json:"retries"`
+	// Backoff is the wait before the first retry, doubled for each next one
+	Backoff Duration `json:"backoff,omitempty"`
+	// Cooldown drops repeats of the same event; empty uses
+	// alerts.cooldown_minutes
+	Cooldown Duration `json:"cooldown,omitempty"`
+}
+
+// Hook runs Command with sh -c when an event matching On happens. On is
+// an event type (alert, security, agent_start, agent_stop,
+// budget_exceeded), optionally narrowed after a colon, e.g.
+// "alert:critical", "security:HIGH", "security:remote_access",
+// "budget_exceeded:team" or "agent_stop:aider".
+type Hook struct {
+	Name    string   `json:"name"`
+	On      string   `json:"on"`
+	Command string   `json:"command"`
+	Timeout Duration `json:"timeout,omitempty"`
+}
+
+// TUIConfig holds dashboard settings the library's display section lacks
+type TUIConfig struct {
+	// TrendWindow is how much history the detail view's sparklines cover
+	TrendWindow Duration `json:"trend_window"`
+	// Layout is the dashboard's starting agent list layout: "cards" or
+	// "table" (one row per agent)
+	Layout string `json:"layout"`
+	// Grouped starts the dashboard with agents clustered by repository
+	Grouped bool `json:"grouped"`
+}
+
+// Duration is a time.Duration that reads and writes strings like "15s"
+type Duration time.Duration
+
+// Duration returns the value as a time.Duration
+func (d Duration) Duration() time.Duration {
+	return time.Duration(d)
+}
+
+// MarshalJSON implements json.Marshaler
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"15s\": %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// ExpandHome replaces a leading ~/ with the user's home directory
+func ExpandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return path
+}
+
+// Default returns the built-in defaults
+func Default() *Config {
+	return &Config{
+		Export: ExportConfig{
+			OTLP: OTLPConfig{
+				Enabled:     false,
+				Endpoint:    "http://localhost:4318",
+				Interval:    Duration(15 * time.Second),
+				Timeout:     Duration(5 * time.Second),
+				ServiceName: "agentmetrics",
+			},
+		},
+		Security: SecurityConfig{
+			Enforcement: EnforcementConfig{Mode: "off"},
+		},
+		Audit: AuditConfig{Enabled: true},
+		TUI:   TUIConfig{TrendWindow: Duration(10 * time.Minute), Layout: "cards"},
+		Notifications: NotificationsConfig{
+			Desktop: DesktopConfig{
+				MinLevel:    "CRITICAL",
+				MinSeverity: "CRITICAL",
+				Interval:    Duration(30 * time.Second),
+			},
+		},
+	}
+}
+
+// Load reads the app sections from the shared config file, falling back
+// to defaults when the file is missing or invalid. An invalid file is
+// reported on stderr; use Read to handle the error instead.
+func Load() *Config {
+	cfg, err := Read()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v (using default settings)\n", err)
+	}
+	return cfg
+}
+
+// Read is Load returning the error. A missing file is not an error.
+func Read() (*Config, error) {
+	return readFrom(config.ConfigPath())
+}
+
+// readFrom returns the defaults with the error when path exists but
+// can't be read or parsed
+func readFrom(path string) (*Config, error) {
+	cfg, err := LoadFrom(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Default(), nil
+	}
+	if err != nil {
+		return Default(), err
+	}
+	return cfg, nil
+}
+
+// LoadLibraryFrom reads the library's sections of the config file at path
+// on top of the library defaults, as config.Load does for the active one
+func LoadLibraryFrom(path string) (*config.Config, error) {
+	cfg := config.DefaultConfig()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// LoadFrom reads the app sections from path on top of the defaults
+func LoadFrom(path string) (*Config, error) {
+	cfg := Default()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return cfg, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,114 @@
+package appconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatalf("writing config: %v", err)
+	}
+	return path
+}
+
+func TestLoadFromMergesOverDefaults(t *testing.T) {
+	path := writeConfig(t, `{
+		"refresh_interval": "3s",
+		"export": {
+			"format": "json",
+			"otlp": {"enabled": true, "interval": "30s"}
+		}
+	}`)
+
+	cfg, err := LoadFrom(path)
+	if err != nil {
+		t.Fatalf("loading config: %v", err)
+	}
+	if !cfg.Export.OTLP.Enabled {
+		t.Fatalf("expected otlp enabled")
+	}
+	if cfg.Export.OTLP.Interval.Duration() != 30*time.Second {
+		t.Fatalf("expected 30s interval, got %s", cfg.Export.OTLP.Interval.Duration())
+	}
+	if cfg.Export.OTLP.Endpoint != "http://localhost:4318" {
+		t.Fatalf("expected default endpoint to be kept, got %q", cfg.Export.OTLP.Endpoint)
+	}
+}
+
+func TestLoadFromMissingFileReturnsDefaults(t *testing.T) {
+	cfg, err := LoadFrom(filepath.Join(t.TempDir(), "missing.json"))
+	if err == nil {
+		t.Fatalf("expected error for missing file")
+	}
+	if cfg.Export.OTLP.ServiceName != "agentmetrics" {
+		t.Fatalf("expected defaults, got %+v", cfg.Export.OTLP)
+	}
+}
+
+func TestReadFromReportsInvalidFiles(t *testing.T) {
+	if _, err := readFrom(filepath.Join(t.TempDir(), "missing.json")); err != nil {
+		t.Fatalf("a missing file is not an error, got %v", err)
+	}
+
+	// A half-parsed file must not leak into the result
+	path := writeConfig(t, `{"tui": {"layout": "table"}, "hooks": 3}`)
+	cfg, err := readFrom(path)
+	if err == nil {
+		t.Fatalf("expected a parse error")
+	}
+	if cfg.TUI.Layout != Default().TUI.Layout {
+		t.Fatalf("expected defaults, got layout %q", cfg.TUI.Layout)
+	}
+}
+
+func TestLoadLibraryFromReadsCandidateFiles(t *testing.T) {
+	cfg, err := LoadLibraryFrom(writeConfig(t, `{"security": {"enabled": true}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !cfg.Security.Enabled {
+		t.Fatalf("expected the file's security section, got %+v", cfg.Security)
+	}
+
+	if _, err := LoadLibraryFrom(writeConfig(t, `{"security": [}`)); err == nil {
+		t.Fatal("expected a parse error")
+	}
+	if _, err := LoadLibraryFrom(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Fatal("a candidate file that doesn't exist is an error")
+	}
+}
+
+func TestDurationRejectsNumbers(t *testing.T) {
+	path := writeConfig(t, `{"export": {"otlp": {"interval": 15}}}`)
+	if _, err := LoadFrom(path); err == nil {
+		t.Fatalf("expected error for numeric duration")
+	}
+}
+
+func TestLoadFromReadsBudgets(t *testing.T) {
+	path := writeConfig(t, `{
+		"budgets": {
+			"enabled": true,
+			"rules": [
+				{"name": "team", "daily_usd": 20, "monthly_usd": 300},
+				{"name": "claude on api", "agent_id": "claude-code", "repo": "~/src/api", "weekly_usd": 50, "action": "stop"}
+			]
+		}
+	}`)
+
+	cfg, err := LoadFrom(path)
+	if err != nil {
+		t.Fatalf("loading config: %v", err)
+	}
+	if !cfg.Budgets.Enabled || len(cfg.Budgets.Rules) != 2 {
+		t.Fatalf("unexpected budgets: %+v", cfg.Budgets)
+	}
+	if r := cfg.Budgets.Rules[1]; r.AgentID != "claude-code" || r.Weekly != 50 || r.Action != "stop" {
+		t.Fatalf("unexpected rule: %+v", r)
+	}
+}
@@ -0,0 +1,280 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Rafiki81/libagentmetrics/config"
+	"github.com/rafaelperezbeato/agentmetrics/internal/collector"
+)
+
+// Entry kinds
+const (
+	KindAlert    = "alert"
+	KindSecurity = "security"
+)
+
+// maxLine bounds one audit line when reading the log back
+const maxLine = 1 << 20
+
+// Entry is one line of the audit log. Hash is the SHA-256 of the entry
+// encoded with an empty Hash, and Prev is the previous entry's Hash, so
+// editing or deleting a line breaks the chain from that point on.
+type Entry struct {
+	Seq   int64           `json:"seq"`
+	Time  time.Time       `json:"time"`
+	Kind  string          `json:"kind"`
+	Event json.RawMessage `json:"event"`
+	Prev  string          `json:"prev"`
+	Hash  string          `json:"hash,omitempty"`
+}
+
+// sum returns the hash the entry should carry
+func (e Entry) sum() (string, error) {
+	e.Hash = ""
+	data, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:]), nil
+}
+
+// DefaultPath returns where the audit log lives next to config.json
+func DefaultPath() string {
+	return filepath.Join(filepath.Dir(config.ConfigPath()), "audit.jsonl")
+}
+
+// Log appends alerts and security events to a hash-chained JSONL file.
+// It implements collector.Sink; each event is written once, as it first
+// appears in the collected state. Several processes (a TUI next to serve,
+// say) may append to the same file: each append holds an exclusive flock
+// while it reads the last entry and writes the next one, so the chain
+// never forks.
+type Log struct {
+	path      string
+	watermark collector.Watermark
+
+	mu sync.Mutex
+	// seq and prev are the head as of this log's last write, valid while
+	// the file is still size bytes long
+	seq     int64
+	prev    string
+	size    int64
+	lastErr error
+}
+
+// Open prepares appending to path, continuing the chain of an existing log
+func Open(path string) (*Log, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Log{path: path}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opening audit log: %w", err)
+	}
+	defer f.Close()
+
+	l := &Log{path: path, size: -1}
+	if l.seq, l.prev, err = readHead(f); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// readHead returns the sequence number and hash of the last entry. An
+// unreadable last line is left for verify to report; the chain restarts
+// after it, numbered by line count, instead of stopping the audit trail.
+func readHead(f *os.File) (int64, string, error) {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return 0, "", fmt.Errorf("reading audit log: %w", err)
+	}
+
+	var last []byte
+	var lines int64
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), maxLine)
+	for sc.Scan() {
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+		lines++
+		last = append(last[:0], sc.Bytes()...)
+	}
+	if err := sc.Err(); err != nil {
+		return 0, "", fmt.Errorf("reading audit log: %w", err)
+	}
+
+	var e Entry
+	if len(last) > 0 && json.Unmarshal(last, &e) == nil {
+		return e.Seq, e.Hash, nil
+	}
+	return lines, "", nil
+}
+
+// Publish appends the alerts and security events that are new since the
+// previous state
+func (l *Log) Publish(st collector.State) {
+	alerts, events := l.watermark.NewEvents(st)
+	for _, al := range alerts {
+		l.setErr(l.Append(KindAlert, al, al.Timestamp))
+	}
+	for _, evt := range events {
+		l.setErr(l.Append(KindSecurity, evt, evt.Timestamp))
+	}
+}
+
+// Append writes one event as the next link of the chain
+func (l *Log) Append(kind string, event any, at time.Time) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encoding %s event: %w", kind, err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o755); err != nil {
+		return fmt.Errorf("creating audit dir: %w", err)
+	}
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_RDWR, 0o600)
+	if err != nil {
+		return fmt.Errorf("opening audit log: %w", err)
+	}
+	defer f.Close()
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		return fmt.Errorf("locking audit log: %w", err)
+	}
+	defer syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+
+	// Another process may have appended since this log last wrote
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("reading audit log: %w", err)
+	}
+	if info.Size() != l.size {
+		if l.seq, l.prev, err = readHead(f); err != nil {
+			return err
+		}
+	}
+
+	e := Entry{Seq: l.seq + 1, Time: at, Kind: kind, Event: data, Prev: l.prev}
+	if e.Hash, err = e.sum(); err != nil {
+		return fmt.Errorf("hashing %s event: %w", kind, err)
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("encoding %s event: %w", kind, err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		// The file may now end in a partial line; read it again next time
+		l.size = -1
+		return fmt.Errorf("writing audit log: %w", err)
+	}
+
+	l.seq, l.prev, l.size = e.Seq, e.Hash, info.Size()+int64(len(line))+1
+	return nil
+}
+
+// Name identifies the log in error reports
+func (l *Log) Name() string {
+	return "audit log"
+}
+
+// LastError returns the most recent write error, if any
+func (l *Log) LastError() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.lastErr
+}
+
+// setErr records the outcome of the latest append; nil clears an earlier
+// failure
+func (l *Log) setErr(err error) {
+	l.mu.Lock()
+	l.lastErr = err
+	l.mu.Unlock()
+}
+
+// Problem is one place where the chain is broken
+type Problem struct {
+	Line   int    `json:"line"`
+	Reason string `json:"reason"`
+}
+
+// Report is the result of verifying a log
+type Report struct {
+	Entries  int       `json:"entries"`
+	Head     string    `json:"head"`
+	Problems []Problem `json:"problems,omitempty"`
+}
+
+// OK reports whether the whole chain is intact
+func (r Report) OK() bool {
+	return len(r.Problems) == 0
+}
+
+// Verify recomputes every hash and link of the log at path. Each broken
+// line is reported once. Lines removed from the end leave a valid chain,
+// so compare Head with a previously recorded value to detect truncation.
+func Verify(path string) (Report, error) {
+	var r Report
+
+	f, err := os.Open(path)
+	if err != nil {
+		return r, fmt.Errorf("opening audit log: %w", err)
+	}
+	defer f.Close()
+
+	var prev *Entry
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), maxLine)
+	for line := 1; sc.Scan(); line++ {
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+		r.Entries++
+		fail := func(format string, args ...any) {
+			r.Problems = append(r.Problems, Problem{Line: line, Reason: fmt.Sprintf(format, args...)})
+		}
+
+		var e Entry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			fail("unreadable entry: %v", err)
+			prev = nil
+			continue
+		}
+
+		if sum, err := e.sum(); err != nil || sum != e.Hash {
+			fail("entry %d was modified (hash mismatch)", e.Seq)
+		}
+		switch {
+		case prev == nil && r.Entries == 1 && (e.Seq != 1 || e.Prev != ""):
+			fail("log does not start at entry 1 (starts at %d)", e.Seq)
+		case prev != nil && e.Seq > prev.Seq+1:
+			fail("entries %d to %d are missing", prev.Seq+1, e.Seq-1)
+		case prev != nil && e.Seq != prev.Seq+1:
+			fail("entry %d is out of order after entry %d", e.Seq, prev.Seq)
+		case prev != nil && e.Prev != prev.Hash:
+			fail("entry %d does not chain to entry %d", e.Seq, prev.Seq)
+		}
+
+		prev = &e
+		r.Head = e.Hash
+	}
+	if err := sc.Err(); err != nil {
+		return r, fmt.Errorf("reading audit log: %w", err)
+	}
+	return r, nil
+}
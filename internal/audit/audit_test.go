@@ -0,0 +1,132 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+	"github.com/rafaelperezbeato/agentmetrics/internal/collector"
+)
+
+func writeLog(t *testing.T, n int) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := Open(path)
+	if err != nil {
+		t.Fatalf("opening log: %v", err)
+	}
+
+	start := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < n; i++ {
+		evt := agent.SecurityEvent{Timestamp: start.Add(time.Duration(i) * time.Second), AgentID: "aider", Detail: "history -c"}
+		if err := l.Append(KindSecurity, evt, evt.Timestamp); err != nil {
+			t.Fatalf("appending: %v", err)
+		}
+	}
+	return path
+}
+
+func TestPublishAppendsEachEventOnceAndChainSurvivesReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	l, err := Open(path)
+	if err != nil {
+		t.Fatalf("opening log: %v", err)
+	}
+
+	now := time.Now()
+	st := collector.State{
+		Alerts:         []agent.Alert{{Timestamp: now, Level: agent.AlertCritical, Message: "cost"}},
+		SecurityEvents: []agent.SecurityEvent{{Timestamp: now, Detail: "sudo su"}},
+	}
+	l.Publish(st)
+	l.Publish(st)
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("reopening log: %v", err)
+	}
+	if err := reopened.Append(KindAlert, agent.Alert{Message: "later"}, now.Add(time.Second)); err != nil {
+		t.Fatalf("appending after reopen: %v", err)
+	}
+
+	r, err := Verify(path)
+	if err != nil {
+		t.Fatalf("verifying: %v", err)
+	}
+	if r.Entries != 3 || !r.OK() {
+		t.Fatalf("expected 3 intact entries, got %+v", r)
+	}
+}
+
+func TestConcurrentWritersKeepOneChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	// A TUI and serve each open the log before either has written
+	tui, err := Open(path)
+	if err != nil {
+		t.Fatalf("opening log: %v", err)
+	}
+	serve, err := Open(path)
+	if err != nil {
+		t.Fatalf("opening log: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for _, l := range []*Log{tui, serve, tui, serve} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 10; i++ {
+				if err := l.Append(KindAlert, agent.Alert{Message: "cost"}, time.Now()); err != nil {
+					t.Errorf("appending: %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	r, err := Verify(path)
+	if err != nil {
+		t.Fatalf("verifying: %v", err)
+	}
+	if r.Entries != 40 || !r.OK() {
+		t.Fatalf("expected 40 chained entries, got %+v", r)
+	}
+}
+
+func TestVerifyDetectsEditedAndDeletedLines(t *testing.T) {
+	path := writeLog(t, 4)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+
+	edited := slices.Clone(lines)
+	edited[1] = strings.Replace(edited[1], "history -c", "ls", 1)
+	if problems := verifyLines(t, path, edited); len(problems) != 1 || problems[0].Line != 2 {
+		t.Fatalf("expected edit on line 2, got %+v", problems)
+	}
+
+	deleted := append(slices.Clone(lines[:2]), lines[3:]...)
+	problems := verifyLines(t, path, deleted)
+	if len(problems) != 1 || problems[0].Line != 3 || !strings.Contains(problems[0].Reason, "missing") {
+		t.Fatalf("expected missing entry before line 3, got %+v", problems)
+	}
+}
+
+func verifyLines(t *testing.T, path string, lines []string) []Problem {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	r, err := Verify(path)
+	if err != nil {
+		t.Fatalf("verifying: %v", err)
+	}
+	return r.Problems
+}
@@ -0,0 +1,277 @@
+package budget
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+	"github.com/Rafiki81/libagentmetrics/monitor"
+	"github.com/rafaelperezbeato/agentmetrics/internal/appconfig"
+	"github.com/rafaelperezbeato/agentmetrics/internal/sessions"
+)
+
+const (
+	// warnFraction of a cap raises a warning alert
+	warnFraction = 0.8
+	// reloadEvery bounds how often finished sessions are re-read from disk
+	reloadEvery = time.Minute
+	// maxAlerts caps the budget alerts kept for display
+	maxAlerts = 20
+)
+
+// Usage is the spend counted against one cap of one budget
+type Usage struct {
+	Budget string    `json:"budget"`
+	Period string    `json:"period"`
+	Since  time.Time `json:"since"`
+	Spent  float64   `json:"spent_usd"`
+	Limit  float64   `json:"limit_usd"`
+	Action string    `json:"action,omitempty"`
+	// PIDs are the live agents the budget covers
+	PIDs []int `json:"pids,omitempty"`
+}
+
+// Fraction returns spent/limit
+func (u Usage) Fraction() float64 {
+	if u.Limit <= 0 {
+		return 0
+	}
+	return u.Spent / u.Limit
+}
+
+// Exceeded reports whether spending went over the cap
+func (u Usage) Exceeded() bool {
+	return u.Limit > 0 && u.Spent > u.Limit
+}
+
+// Tracker sums finished-session cost from the report store with the live
+// cost of running agents and raises alerts as caps are approached. Enforce
+// applies the configured action once they are exceeded.
+type Tracker struct {
+	rules    []appconfig.Budget
+	store    *sessions.Store
+	reports  []sessions.Report
+	loadedAt time.Time
+	notified map[string]bool
+	enforced map[string]bool
+	alerts   []agent.Alert
+	signal   func(pid int, sig syscall.Signal) error
+	// costs splits live sessions' cost by day, so a session that spans
+	// a period boundary only counts what it spent within the period
+	costs *sessions.Ledger
+}
+
+// NewTracker creates a tracker reading finished sessions from store
+func NewTracker(cfg appconfig.BudgetsConfig, store *sessions.Store) *Tracker {
+	return &Tracker{
+		rules:    cfg.Rules,
+		store:    store,
+		notified: map[string]bool{},
+		enforced: map[string]bool{},
+		signal:   syscall.Kill,
+		costs:    sessions.NewLedger(),
+	}
+}
+
+// Record adds a session that just finished, so its cost is counted before
+// the next reload from disk
+func (t *Tracker) Record(r sessions.Report) {
+	t.reports = append(t.reports, r)
+}
+
+// Alerts returns recent budget alerts, oldest first
+func (t *Tracker) Alerts() []agent.Alert {
+	return t.alerts
+}
+
+// Check computes usage for every cap and alerts on the ones running out
+func (t *Tracker) Check(agents []agent.Instance, now time.Time) []Usage {
+	t.reload(now)
+	finished := sessions.WithoutRunning(t.reports, agents)
+	t.costs.Observe(agents, now)
+	defer t.costs.Forget(agents)
+
+	var usage []Usage
+	for _, rule := range t.rules {
+		for _, c := range caps(rule) {
+			u := Usage{
+				Budget: rule.Name,
+				Period: c.period,
+				Since:  periodStart(c.period, now),
+				Limit:  c.limit,
+				Action: rule.Action,
+			}
+			// Only what each session spent within the period counts
+			for _, r := range finished {
+				if matches(rule, r.AgentID, r.WorkDir) {
+					u.Spent += r.CostSince(u.Since)
+				}
+			}
+			for _, a := range agents {
+				if matches(rule, a.Info.ID, a.WorkDir) {
+					u.Spent += t.costs.Days(a.PID).Since(u.Since)
+					u.PIDs = append(u.PIDs, a.PID)
+				}
+			}
+
+			t.notify(u, now)
+			usage = append(usage, u)
+		}
+	}
+	return usage
+}
+
+// reload re-reads finished sessions, keeping the last good list on error
+func (t *Tracker) reload(now time.Time) {
+	if t.store == nil || now.Sub(t.loadedAt) < reloadEvery {
+		return
+	}
+	t.loadedAt = now
+
+	reports, err := t.store.List()
+	if err != nil {
+		return
+	}
+	// Nothing older than the start of the month can count
+	oldest := periodStart("monthly", now).AddDate(0, 0, -7)
+	t.reports = slices.DeleteFunc(reports, func(r sessions.Report) bool {
+		return r.EndedAt.Before(oldest)
+	})
+}
+
+// notify raises one alert per cap and period when it crosses the warning
+// fraction and again when it is exceeded
+func (t *Tracker) notify(u Usage, now time.Time) {
+	level, what := agent.AlertWarning, fmt.Sprintf("%.0f%% of", u.Fraction()*100)
+	if u.Exceeded() {
+		level, what = agent.AlertCritical, "exceeded"
+	} else if u.Fraction() < warnFraction {
+		return
+	}
+
+	key := fmt.Sprintf("%s|%s|%s|%s", u.Budget, u.Period, u.Since.Format(time.DateOnly), level)
+	if t.notified[key] {
+		return
+	}
+	t.notified[key] = true
+
+	t.addAlert(agent.Alert{
+		Timestamp: now,
+		Level:     level,
+		AgentName: "Budget " + u.Budget,
+		Message: fmt.Sprintf("%s cap %s: %s / %s",
+			u.Period, what, monitor.FormatCost(u.Spent), monitor.FormatCost(u.Limit)),
+	})
+}
+
+// Enforce signals every agent covered by an exceeded cap, once per cap
+// and period. Only collectors in collector.Act mode call it.
+func (t *Tracker) Enforce(usage []Usage, agents []agent.Instance, now time.Time) {
+	for _, u := range usage {
+		if u.Exceeded() {
+			t.enforce(u, agents, now)
+		}
+	}
+}
+
+func (t *Tracker) enforce(u Usage, agents []agent.Instance, now time.Time) {
+	var sig syscall.Signal
+	switch u.Action {
+	case "stop":
+		sig = syscall.SIGSTOP
+	case "term":
+		sig = syscall.SIGTERM
+	default:
+		return
+	}
+
+	for _, a := range agents {
+		if !slices.Contains(u.PIDs, a.PID) {
+			continue
+		}
+		key := fmt.Sprintf("%s|%s|%s|%d", u.Budget, u.Period, u.Since.Format(time.DateOnly), a.PID)
+		if t.enforced[key] {
+			continue
+		}
+		t.enforced[key] = true
+
+		msg := fmt.Sprintf("budget %q %s cap exceeded: sent %s to PID %d", u.Budget, u.Period, signalName(sig), a.PID)
+		if err := t.signal(a.PID, sig); err != nil {
+			msg = fmt.Sprintf("budget %q %s cap exceeded: sending %s to PID %d failed: %v", u.Budget, u.Period, signalName(sig), a.PID, err)
+		}
+		t.addAlert(agent.Alert{
+			Timestamp: now,
+			Level:     agent.AlertCritical,
+			AgentID:   a.Info.ID,
+			AgentName: a.Info.Name,
+			Message:   msg,
+		})
+	}
+}
+
+func (t *Tracker) addAlert(al agent.Alert) {
+	t.alerts = append(t.alerts, al)
+	if len(t.alerts) > maxAlerts {
+		t.alerts = t.alerts[len(t.alerts)-maxAlerts:]
+	}
+}
+
+type budgetCap struct {
+	period string
+	limit  float64
+}
+
+func caps(rule appconfig.Budget) []budgetCap {
+	var out []budgetCap
+	for _, c := range []budgetCap{
+		{"daily", rule.Daily},
+		{"weekly", rule.Weekly},
+		{"monthly", rule.Monthly},
+	} {
+		if c.limit > 0 {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// periodStart returns local midnight of the current day, Monday of the
+// current week or the first of the current month
+func periodStart(period string, now time.Time) time.Time {
+	now = now.Local()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch period {
+	case "weekly":
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case "monthly":
+		return day.AddDate(0, 0, 1-day.Day())
+	default:
+		return day
+	}
+}
+
+// matches reports whether an agent in workDir falls under rule
+func matches(rule appconfig.Budget, agentID, workDir string) bool {
+	if rule.AgentID != "" && rule.AgentID != agentID {
+		return false
+	}
+	if rule.Repo != "" {
+		repo := filepath.Clean(appconfig.ExpandHome(rule.Repo))
+		workDir = filepath.Clean(workDir)
+		if workDir != repo && !strings.HasPrefix(workDir, repo+string(filepath.Separator)) {
+			return false
+		}
+	}
+	return true
+}
+
+func signalName(sig syscall.Signal) string {
+	if sig == syscall.SIGSTOP {
+		return "SIGSTOP"
+	}
+	return "SIGTERM"
+}
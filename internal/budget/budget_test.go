@@ -0,0 +1,146 @@
+package budget
+
+import (
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+	"github.com/rafaelperezbeato/agentmetrics/internal/appconfig"
+	"github.com/rafaelperezbeato/agentmetrics/internal/sessions"
+)
+
+func liveAgent(pid int, id, dir string, cost float64) agent.Instance {
+	a := agent.Instance{PID: pid, WorkDir: dir}
+	a.Info.ID = id
+	a.Info.Name = id
+	a.Tokens.EstCost = cost
+	// Started on the day the tests run, so its whole cost is that day's
+	a.Session.StartedAt = time.Date(2026, 2, 18, 8, 0, 0, 0, time.Local)
+	return a
+}
+
+func TestCheckSumsHistoryAndLiveCostInScope(t *testing.T) {
+	now := time.Date(2026, 2, 18, 15, 0, 0, 0, time.Local) // a Wednesday
+	tr := NewTracker(appconfig.BudgetsConfig{Rules: []appconfig.Budget{
+		{Name: "api", AgentID: "claude-code", Repo: "/src/api", Daily: 10, Weekly: 40},
+	}}, nil)
+
+	tr.Record(sessions.Report{AgentID: "claude-code", WorkDir: "/src/api", EndedAt: now.Add(-2 * time.Hour), Cost: 3})
+	tr.Record(sessions.Report{AgentID: "claude-code", WorkDir: "/src/api/cmd", EndedAt: now.AddDate(0, 0, -1), Cost: 5})
+	tr.Record(sessions.Report{AgentID: "claude-code", WorkDir: "/src/api", EndedAt: now.AddDate(0, 0, -7), Cost: 100})
+	tr.Record(sessions.Report{AgentID: "aider", WorkDir: "/src/api", EndedAt: now, Cost: 100})
+	tr.Record(sessions.Report{AgentID: "claude-code", WorkDir: "/src/apiserver", EndedAt: now, Cost: 100})
+
+	usage := tr.Check([]agent.Instance{
+		liveAgent(10, "claude-code", "/src/api", 2),
+		liveAgent(11, "claude-code", "/src/web", 50),
+	}, now)
+
+	if len(usage) != 2 {
+		t.Fatalf("expected daily and weekly usage, got %+v", usage)
+	}
+	if u := usage[0]; u.Period != "daily" || u.Spent != 5 || len(u.PIDs) != 1 || u.PIDs[0] != 10 {
+		t.Fatalf("unexpected daily usage: %+v", u)
+	}
+	if u := usage[1]; u.Period != "weekly" || u.Spent != 10 {
+		t.Fatalf("unexpected weekly usage: %+v", u)
+	}
+	if len(tr.Alerts()) != 0 {
+		t.Fatalf("expected no alerts under 80%%, got %+v", tr.Alerts())
+	}
+}
+
+func TestCheckAlertsOnceAndEnforcesOnExceeded(t *testing.T) {
+	now := time.Date(2026, 2, 18, 15, 0, 0, 0, time.Local)
+	tr := NewTracker(appconfig.BudgetsConfig{Rules: []appconfig.Budget{
+		{Name: "global", Daily: 10, Action: "stop"},
+	}}, nil)
+
+	var signalled []int
+	tr.signal = func(pid int, sig syscall.Signal) error {
+		if sig != syscall.SIGSTOP {
+			t.Fatalf("expected SIGSTOP, got %v", sig)
+		}
+		signalled = append(signalled, pid)
+		return nil
+	}
+
+	agents := []agent.Instance{liveAgent(10, "claude-code", "/src", 9)}
+	tr.Enforce(tr.Check(agents, now), agents, now)
+	if len(tr.Alerts()) != 1 || tr.Alerts()[0].Level != agent.AlertWarning {
+		t.Fatalf("expected one warning at 90%%, got %+v", tr.Alerts())
+	}
+	if len(signalled) != 0 {
+		t.Fatalf("expected no enforcement under the cap")
+	}
+
+	agents = []agent.Instance{liveAgent(10, "claude-code", "/src", 12)}
+	for i := 1; i <= 2; i++ {
+		at := now.Add(time.Duration(i) * time.Minute)
+		tr.Enforce(tr.Check(agents, at), agents, at)
+	}
+
+	if len(signalled) != 1 || signalled[0] != 10 {
+		t.Fatalf("expected PID 10 stopped once, got %v", signalled)
+	}
+	// warning, exceeded, enforcement
+	if len(tr.Alerts()) != 3 {
+		t.Fatalf("expected 3 alerts, got %+v", tr.Alerts())
+	}
+}
+
+func TestPeriodStart(t *testing.T) {
+	now := time.Date(2026, 2, 18, 15, 4, 5, 0, time.Local) // Wednesday
+
+	cases := map[string]time.Time{
+		"daily":   time.Date(2026, 2, 18, 0, 0, 0, 0, time.Local),
+		"weekly":  time.Date(2026, 2, 16, 0, 0, 0, 0, time.Local),
+		"monthly": time.Date(2026, 2, 1, 0, 0, 0, 0, time.Local),
+	}
+	for period, want := range cases {
+		if got := periodStart(period, now); !got.Equal(want) {
+			t.Fatalf("%s: expected %s, got %s", period, want, got)
+		}
+	}
+}
+
+func TestCheckCountsOnlyCostSpentWithinThePeriod(t *testing.T) {
+	evening := time.Date(2026, 2, 17, 23, 50, 0, 0, time.Local)
+	tr := NewTracker(appconfig.BudgetsConfig{Rules: []appconfig.Budget{
+		{Name: "global", Daily: 10, Weekly: 100, Action: "term"},
+	}}, nil)
+	var signalled []int
+	tr.signal = func(pid int, sig syscall.Signal) error {
+		signalled = append(signalled, pid)
+		return nil
+	}
+
+	// A session running since yesterday morning has spent $30 so far
+	long := liveAgent(10, "claude-code", "/src", 30)
+	long.Session.StartedAt = evening.Add(-15 * time.Hour)
+	tr.Enforce(tr.Check([]agent.Instance{long}, evening), []agent.Instance{long}, evening)
+	signalled = nil
+
+	// Just after midnight it has spent another $1, all of today's spend
+	long.Tokens.EstCost = 31
+	afterMidnight := evening.Add(15 * time.Minute)
+	usage := tr.Check([]agent.Instance{long}, afterMidnight)
+	tr.Enforce(usage, []agent.Instance{long}, afterMidnight)
+	if u := usage[0]; u.Period != "daily" || u.Spent != 1 {
+		t.Fatalf("expected $1 spent today, got %+v", u)
+	}
+	if u := usage[1]; u.Period != "weekly" || u.Spent != 31 {
+		t.Fatalf("expected the whole session this week, got %+v", u)
+	}
+	if len(signalled) != 0 {
+		t.Fatalf("an agent within today's cap must not be signalled, got %v", signalled)
+	}
+
+	// A session that ended after midnight is split the same way
+	tr.Record(sessions.Report{AgentID: "aider", PID: 11, EndedAt: afterMidnight, Cost: 50,
+		DailyCost: sessions.DailyCost{"2026-02-17": 48, "2026-02-18": 2}})
+	if u := tr.Check([]agent.Instance{long}, afterMidnight.Add(time.Minute))[0]; u.Spent != 3 {
+		t.Fatalf("expected $3 spent today, got %+v", u)
+	}
+}
@@ -2,12 +2,24 @@ package cli
 
 import (
 	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/Rafiki81/libagentmetrics/agent"
 	"github.com/Rafiki81/libagentmetrics/monitor"
+	"github.com/rafaelperezbeato/agentmetrics/internal/appconfig"
+	"github.com/rafaelperezbeato/agentmetrics/internal/budget"
 )
 
 func runAlerts() error {
+	if st, ok := attachDaemon(); ok {
+		printBudgets(st.Budgets)
+		printAlerts(st.Alerts)
+		return nil
+	}
+
 	runtime := newScanRuntime()
 
 	agents, err := runtime.scan()
@@ -36,9 +48,59 @@ func runAlerts() error {
 	}
 
 	alerts := alertMon.GetAlerts()
+
+	// Report budget usage without enforcing; only long-running
+	// collectors signal agents
+	if appCfg := appconfig.Load(); appCfg.Budgets.Enabled {
+		tracker := budget.NewTracker(appCfg.Budgets, newSessionStore(runtime.cfg))
+		printBudgets(tracker.Check(agents, time.Now()))
+		alerts = append(alerts, tracker.Alerts()...)
+	}
+
+	printAlerts(alerts)
+	return nil
+}
+
+// printBudgets renders a progress bar per budget cap
+func printBudgets(usage []budget.Usage) {
+	if len(usage) == 0 {
+		return
+	}
+
+	fmt.Println("💰 Budgets:")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, u := range usage {
+		icon := " "
+		if u.Exceeded() {
+			icon = "🔴"
+		} else if u.Fraction() >= 0.8 {
+			icon = "⚠"
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%3.0f%%\t%s / %s\t%s\n",
+			u.Budget,
+			u.Period,
+			textBar(u.Fraction(), 20),
+			u.Fraction()*100,
+			monitor.FormatCost(u.Spent),
+			monitor.FormatCost(u.Limit),
+			icon,
+		)
+	}
+	w.Flush()
+	fmt.Println()
+}
+
+// textBar renders fraction (0..1) as [#####-----]
+func textBar(fraction float64, width int) string {
+	filled := int(fraction * float64(width))
+	filled = max(0, min(filled, width))
+	return "[" + strings.Repeat("#", filled) + strings.Repeat("-", width-filled) + "]"
+}
+
+func printAlerts(alerts []agent.Alert) {
 	if len(alerts) == 0 {
 		fmt.Println("✅ No active alerts.")
-		return nil
+		return
 	}
 
 	fmt.Printf("⚡ %d active alert(s):\n\n", len(alerts))
@@ -58,6 +120,4 @@ func runAlerts() error {
 			al.Message,
 		)
 	}
-
-	return nil
 }
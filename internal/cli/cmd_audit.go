@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/rafaelperezbeato/agentmetrics/internal/appconfig"
+	"github.com/rafaelperezbeato/agentmetrics/internal/audit"
+)
+
+func runAudit(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: agentmetrics audit verify [path] | audit path")
+	}
+
+	path := auditPath(appconfig.Load())
+	switch args[0] {
+	case "path":
+		fmt.Println(path)
+		return nil
+	case "verify":
+		if len(args) > 1 {
+			path = appconfig.ExpandHome(args[1])
+		}
+	default:
+		return fmt.Errorf("unknown audit command: %s (use 'verify' or 'path')", args[0])
+	}
+
+	r, err := audit.Verify(path)
+	if err != nil {
+		return err
+	}
+	if r.OK() {
+		fmt.Printf("✅ %s: %d entries, chain intact\n", path, r.Entries)
+		fmt.Printf("   head %s\n", r.Head)
+		return nil
+	}
+
+	fmt.Printf("❌ %s: %d entries, %d problem(s)\n", path, r.Entries, len(r.Problems))
+	for _, p := range r.Problems {
+		fmt.Printf("   line %d: %s\n", p.Line, p.Reason)
+	}
+	return fmt.Errorf("audit log has been tampered with")
+}
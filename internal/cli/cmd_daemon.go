@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/Rafiki81/libagentmetrics/config"
+	"github.com/rafaelperezbeato/agentmetrics/internal/appconfig"
+	"github.com/rafaelperezbeato/agentmetrics/internal/daemon"
+	"github.com/rafaelperezbeato/agentmetrics/internal/rules"
+)
+
+func runDaemon(args []string) error {
+	path := daemon.SocketPath()
+
+	if len(args) > 0 {
+		switch args[0] {
+		case "status":
+			client, err := daemon.Dial(path)
+			if err != nil {
+				fmt.Printf("Daemon not running (%s)\n", path)
+				return nil
+			}
+			st, err := client.State()
+			if err != nil {
+				fmt.Printf("Daemon running on %s (%v)\n", path, err)
+				return nil
+			}
+			fmt.Printf("Daemon running on %s\n", path)
+			fmt.Printf("  Last collection: %s\n", st.Timestamp.Format("15:04:05"))
+			fmt.Printf("  Agents:          %d\n", len(st.Agents))
+			for _, e := range st.SinkErrors {
+				fmt.Printf("  ⚠ %s\n", e)
+			}
+			return nil
+		case "stop":
+			client, err := daemon.Dial(path)
+			if err != nil {
+				return fmt.Errorf("daemon not running: %w", err)
+			}
+			if err := client.Stop(); err != nil {
+				return fmt.Errorf("stopping daemon: %w", err)
+			}
+			fmt.Println("Daemon stopped.")
+			return nil
+		default:
+			return fmt.Errorf("unknown daemon command: %s (use 'status' or 'stop')", args[0])
+		}
+	}
+
+	// The daemon enforces and notifies unattended, so it won't run on
+	// defaults silently standing in for a broken config
+	cfg := config.Load()
+	appCfg, err := appconfig.Read()
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	warnInvalidRules(appCfg)
+	srv := daemon.NewServer(cfg, appCfg, path)
+	sinks, closeSinks := newSinks(cfg, appCfg)
+	defer closeSinks()
+	for _, sink := range sinks {
+		srv.AddSink(sink)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Printf("AgentMetrics daemon listening on %s (Ctrl+C to stop)\n", path)
+	return srv.Run(ctx)
+}
+
+// warnInvalidRules reports custom security rules that will be skipped
+func warnInvalidRules(appCfg *appconfig.Config) {
+	for _, err := range rules.New(appCfg.Security.CustomRules).Errors() {
+		fmt.Fprintf(os.Stderr, "Warning: skipping custom security %v\n", err)
+	}
+}
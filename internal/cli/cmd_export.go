@@ -19,13 +19,18 @@ func runExport(args []string) error {
 
 	runtime := newScanRuntime()
 
-	agents, err := runtime.scan()
-	if err != nil {
-		return err
-	}
+	st, attached := attachDaemon()
+	agents := st.Agents
+	if !attached {
+		var err error
+		agents, err = runtime.scan()
+		if err != nil {
+			return err
+		}
 
-	collectTokenMetrics(agents)
-	collectGitAndSessionMetrics(agents)
+		collectTokenMetrics(agents)
+		collectGitAndSessionMetrics(agents)
+	}
 
 	history := monitor.NewHistoryStore(runtime.cfg.Export.Directory, runtime.cfg.Export.MaxHistory)
 	history.Record(agents)
@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/Rafiki81/libagentmetrics/config"
+	"github.com/Rafiki81/libagentmetrics/monitor"
+	"github.com/rafaelperezbeato/agentmetrics/internal/appconfig"
+	"github.com/rafaelperezbeato/agentmetrics/internal/collector"
+	"github.com/rafaelperezbeato/agentmetrics/internal/groups"
+)
+
+func runGroups(args []string) error {
+	fs := flag.NewFlagSet("groups", flag.ContinueOnError)
+	format := fs.String("format", "table", "output format: table, json or csv")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	st, ok := attachDaemon()
+	if !ok {
+		var err error
+		st, err = collector.New(config.Load(), appconfig.Load(), collector.ReportOnly).Collect()
+		if err != nil {
+			return err
+		}
+	}
+	list := groups.Build(st.Agents)
+
+	switch *format {
+	case "table":
+		writeGroupsTable(os.Stdout, list)
+		return nil
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(list)
+	case "csv":
+		return writeGroupsCSV(os.Stdout, list)
+	default:
+		return fmt.Errorf("unknown format: %s (use 'table', 'json' or 'csv')", *format)
+	}
+}
+
+// writeGroupsTable prints one row per repository
+func writeGroupsTable(out io.Writer, list []groups.Group) {
+	if len(list) == 0 {
+		fmt.Fprintln(out, "No active agents detected.")
+		return
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "GROUP\tAGENTS\tTOKENS\tCOST\tLOC\tUNCOMMITTED\tROOT\n")
+	fmt.Fprintf(w, "-----\t------\t------\t----\t---\t-----------\t----\n")
+	for _, g := range list {
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t+%d/-%d\t%d\t%s\n",
+			g.Name,
+			g.Agents,
+			monitor.FormatTokenCount(g.TotalTokens),
+			monitor.FormatCost(g.Cost),
+			g.LOCAdded,
+			g.LOCRemoved,
+			g.Uncommitted,
+			g.Root,
+		)
+	}
+	w.Flush()
+}
+
+// writeGroupsCSV writes raw numbers so spreadsheets can sum them
+func writeGroupsCSV(out io.Writer, list []groups.Group) error {
+	w := csv.NewWriter(out)
+	_ = w.Write([]string{"name", "root", "repo", "agents", "total_tokens", "cost_usd", "loc_added", "loc_removed", "uncommitted"})
+	for _, g := range list {
+		_ = w.Write([]string{
+			g.Name,
+			g.Root,
+			strconv.FormatBool(g.Repo),
+			strconv.Itoa(g.Agents),
+			strconv.FormatInt(g.TotalTokens, 10),
+			strconv.FormatFloat(g.Cost, 'f', 4, 64),
+			strconv.Itoa(g.LOCAdded),
+			strconv.Itoa(g.LOCRemoved),
+			strconv.Itoa(g.Uncommitted),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
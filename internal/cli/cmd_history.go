@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+	"github.com/Rafiki81/libagentmetrics/config"
+	"github.com/Rafiki81/libagentmetrics/monitor"
+	"github.com/rafaelperezbeato/agentmetrics/internal/sessions"
+)
+
+func runHistory(args []string) error {
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	agentFlag := fs.String("agent", "", "agent ID or name")
+	workdir := fs.String("workdir", "", "working directory (substring match)")
+	model := fs.String("model", "", "model name (substring match)")
+	since := fs.String("since", "7d", "start: YYYY-MM-DD or age such as 7d, 2w, 36h")
+	until := fs.String("until", "", "end (exclusive): YYYY-MM-DD or age")
+	group := fs.String("group", "day", "aggregate by: day or week")
+	format := fs.String("format", "table", "output format: table, json or csv")
+	live := fs.Bool("live", true, "include agents that are still running")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	filter := sessions.Filter{Agent: *agentFlag, WorkDir: *workdir, Model: *model}
+	var err error
+	if filter.Since, err = sessions.ParseSince(*since, now); err != nil {
+		return err
+	}
+	if filter.Until, err = sessions.ParseSince(*until, now); err != nil {
+		return err
+	}
+	period, err := sessions.ParsePeriod(*group)
+	if err != nil {
+		return err
+	}
+
+	reports, err := newSessionStore(config.Load()).List()
+	if err != nil {
+		return err
+	}
+	// Reports are written when an agent exits, so running agents are
+	// summarised from the live state
+	if *live {
+		agents, err := liveAgents()
+		if err != nil {
+			return err
+		}
+		reports = append(sessions.WithoutRunning(reports, agents), sessions.Live(agents, now)...)
+	}
+	buckets := sessions.Aggregate(filter.Apply(reports), period)
+
+	switch *format {
+	case "table":
+		writeHistoryTable(os.Stdout, buckets)
+		return nil
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(buckets)
+	case "csv":
+		return writeHistoryCSV(os.Stdout, buckets)
+	default:
+		return fmt.Errorf("unknown format: %s (use 'table', 'json' or 'csv')", *format)
+	}
+}
+
+// liveAgents returns the running agents from the daemon, or a local scan
+func liveAgents() ([]agent.Instance, error) {
+	if st, ok := attachDaemon(); ok {
+		return st.Agents, nil
+	}
+	agents, err := newScanRuntime().scan()
+	if err != nil {
+		return nil, err
+	}
+	collectTokenMetrics(agents)
+	collectGitAndSessionMetrics(agents)
+	return agents, nil
+}
+
+// writeHistoryTable prints one row per period plus a total
+func writeHistoryTable(out io.Writer, buckets []sessions.Bucket) {
+	if len(buckets) == 0 {
+		fmt.Fprintln(out, "No sessions match the filters.")
+		return
+	}
+
+	var total sessions.Bucket
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "PERIOD\tSESSIONS\tTOKENS\tCOST\tACTIVE\tLOC\n")
+	fmt.Fprintf(w, "------\t--------\t------\t----\t------\t---\n")
+	for _, b := range buckets {
+		writeHistoryRow(w, b.Period, b)
+		total.Sessions += b.Sessions
+		total.Live += b.Live
+		total.TotalTokens += b.TotalTokens
+		total.Cost += b.Cost
+		total.ActiveSecs += b.ActiveSecs
+		total.LOCAdded += b.LOCAdded
+		total.LOCRemoved += b.LOCRemoved
+	}
+	fmt.Fprintf(w, "\t\t\t\t\t\n")
+	writeHistoryRow(w, "TOTAL", total)
+	w.Flush()
+}
+
+func writeHistoryRow(w io.Writer, label string, b sessions.Bucket) {
+	sessionCount := strconv.Itoa(b.Sessions)
+	if b.Live > 0 {
+		sessionCount += fmt.Sprintf(" (%d live)", b.Live)
+	}
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t+%d/-%d\n",
+		label,
+		sessionCount,
+		monitor.FormatTokenCount(b.TotalTokens),
+		monitor.FormatCost(b.Cost),
+		monitor.FormatDuration(secs(b.ActiveSecs)),
+		b.LOCAdded,
+		b.LOCRemoved,
+	)
+}
+
+// writeHistoryCSV writes raw numbers so spreadsheets can sum them
+func writeHistoryCSV(out io.Writer, buckets []sessions.Bucket) error {
+	w := csv.NewWriter(out)
+	_ = w.Write([]string{"period", "start", "sessions", "total_tokens", "cost_usd", "active_secs", "loc_added", "loc_removed", "live"})
+	for _, b := range buckets {
+		_ = w.Write([]string{
+			b.Period,
+			b.Start.Format("2006-01-02"),
+			strconv.Itoa(b.Sessions),
+			strconv.FormatInt(b.TotalTokens, 10),
+			strconv.FormatFloat(b.Cost, 'f', 4, 64),
+			strconv.FormatInt(b.ActiveSecs, 10),
+			strconv.Itoa(b.LOCAdded),
+			strconv.Itoa(b.LOCRemoved),
+			strconv.Itoa(b.Live),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
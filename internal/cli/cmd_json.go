@@ -2,26 +2,67 @@ package cli
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/Rafiki81/libagentmetrics/agent"
+	"github.com/Rafiki81/libagentmetrics/config"
+	"github.com/rafaelperezbeato/agentmetrics/internal/appconfig"
+	"github.com/rafaelperezbeato/agentmetrics/internal/collector"
+	"github.com/rafaelperezbeato/agentmetrics/internal/groups"
+	"github.com/rafaelperezbeato/agentmetrics/internal/lifecycle"
 )
 
-func runJSON() error {
-	runtime := newScanRuntime()
+// jsonSections are the parts of the output that --with can select
+var jsonSections = []string{
+	"tokens", "git", "session", "terminal", "network", "files",
+	"alerts", "security", "local_models", "lifecycle", "groups",
+}
+
+// daemonSections need a long-running collector: file operations are
+// recorded by a background watcher and lifecycle transitions are diffs
+// between scans, so a single local scan would always report them empty
+var daemonSections = []string{"files", "lifecycle"}
+
+// jsonDocument is the top-level `agentmetrics json` output
+type jsonDocument struct {
+	Timestamp      time.Time              `json:"timestamp"`
+	Agents         []agent.Instance       `json:"agents"`
+	Alerts         []agent.Alert          `json:"alerts,omitempty"`
+	SecurityEvents []agent.SecurityEvent  `json:"security_events,omitempty"`
+	LocalModels    []agent.LocalModelInfo `json:"local_models,omitempty"`
+	Lifecycle      []lifecycle.Event      `json:"lifecycle,omitempty"`
+	Groups         []groups.Group         `json:"groups,omitempty"`
+}
+
+func runJSON(args []string) error {
+	fs := flag.NewFlagSet("json", flag.ContinueOnError)
+	with := fs.String("with", "", "comma-separated sections to include: "+strings.Join(jsonSections, ","))
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
 
-	agents, err := runtime.scan()
+	sections, err := parseSections(*with)
 	if err != nil {
 		return err
 	}
 
-	snapshot := agent.Snapshot{
-		Timestamp: time.Now(),
-		Agents:    agents,
+	st, ok := attachDaemon()
+	if !ok {
+		if err := dropDaemonSections(sections, strings.TrimSpace(*with) != ""); err != nil {
+			return err
+		}
+		var err error
+		st, err = collector.New(config.Load(), appconfig.Load(), collector.ReportOnly).Collect()
+		if err != nil {
+			return err
+		}
 	}
 
-	data, err := json.MarshalIndent(snapshot, "", "  ")
+	data, err := json.MarshalIndent(buildJSONDocument(st, sections), "", "  ")
 	if err != nil {
 		return fmt.Errorf("serializing JSON: %w", err)
 	}
@@ -29,3 +70,93 @@ func runJSON() error {
 	fmt.Println(string(data))
 	return nil
 }
+
+// parseSections turns a --with value into a set; empty means everything
+func parseSections(value string) (map[string]bool, error) {
+	sections := map[string]bool{}
+	if strings.TrimSpace(value) == "" {
+		for _, name := range jsonSections {
+			sections[name] = true
+		}
+		return sections, nil
+	}
+
+	valid := map[string]bool{}
+	for _, name := range jsonSections {
+		valid[name] = true
+	}
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !valid[name] {
+			known := append([]string(nil), jsonSections...)
+			sort.Strings(known)
+			return nil, fmt.Errorf("unknown section: %s (use %s)", name, strings.Join(known, ", "))
+		}
+		sections[name] = true
+	}
+	return sections, nil
+}
+
+// dropDaemonSections removes daemonSections for a one-shot scan. Asking
+// for one explicitly is an error rather than an empty result.
+func dropDaemonSections(sections map[string]bool, explicit bool) error {
+	for _, name := range daemonSections {
+		if explicit && sections[name] {
+			return fmt.Errorf("section %s needs a running daemon (start one with agentmetrics daemon)", name)
+		}
+		delete(sections, name)
+	}
+	return nil
+}
+
+// buildJSONDocument drops every section that was not selected
+func buildJSONDocument(st collector.State, sections map[string]bool) jsonDocument {
+	var zero agent.Instance
+
+	doc := jsonDocument{
+		Timestamp: st.Timestamp,
+		Agents:    make([]agent.Instance, len(st.Agents)),
+	}
+	for i, a := range st.Agents {
+		if !sections["tokens"] {
+			a.Tokens = zero.Tokens
+		}
+		if !sections["git"] {
+			a.Git = zero.Git
+			a.LOC = zero.LOC
+		}
+		if !sections["session"] {
+			a.Session = zero.Session
+		}
+		if !sections["terminal"] {
+			a.Terminal = zero.Terminal
+		}
+		if !sections["network"] {
+			a.NetConns = nil
+		}
+		if !sections["files"] {
+			a.FileOps = nil
+		}
+		doc.Agents[i] = a
+	}
+
+	if sections["alerts"] {
+		doc.Alerts = st.Alerts
+	}
+	if sections["security"] {
+		doc.SecurityEvents = st.SecurityEvents
+	}
+	if sections["local_models"] {
+		doc.LocalModels = st.LocalModels
+	}
+	if sections["lifecycle"] {
+		doc.Lifecycle = st.Lifecycle
+	}
+	if sections["groups"] {
+		doc.Groups = groups.Build(st.Agents)
+	}
+	return doc
+}
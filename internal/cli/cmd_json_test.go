@@ -0,0 +1,96 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+	"github.com/rafaelperezbeato/agentmetrics/internal/collector"
+)
+
+func TestParseSectionsDefaultsToAll(t *testing.T) {
+	sections, err := parseSections("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(sections) != len(jsonSections) {
+		t.Fatalf("expected all %d sections, got %d", len(jsonSections), len(sections))
+	}
+}
+
+func TestParseSectionsRejectsUnknown(t *testing.T) {
+	if _, err := parseSections("tokens,bogus"); err == nil {
+		t.Fatalf("expected error for unknown section")
+	}
+}
+
+func TestBuildJSONDocumentKeepsOnlySelectedSections(t *testing.T) {
+	a := agent.Instance{PID: 1}
+	a.Tokens.TotalTokens = 500
+	a.Git.Branch = "main"
+	a.LOC.Added = 10
+
+	st := collector.State{
+		Agents:         []agent.Instance{a},
+		Alerts:         []agent.Alert{{Message: "cpu"}},
+		SecurityEvents: []agent.SecurityEvent{{Description: "sudo"}},
+	}
+
+	sections, _ := parseSections("tokens,security")
+	doc := buildJSONDocument(st, sections)
+
+	got := doc.Agents[0]
+	if got.Tokens.TotalTokens != 500 {
+		t.Fatalf("expected tokens to be kept")
+	}
+	if got.Git.Branch != "" || got.LOC.Added != 0 {
+		t.Fatalf("expected git and LOC to be dropped, got %+v %+v", got.Git, got.LOC)
+	}
+	if doc.Alerts != nil {
+		t.Fatalf("expected alerts to be dropped")
+	}
+	if len(doc.SecurityEvents) != 1 {
+		t.Fatalf("expected security events to be kept")
+	}
+	if st.Agents[0].Git.Branch != "main" {
+		t.Fatalf("expected input state to be left untouched")
+	}
+}
+
+func TestBuildJSONDocumentGroupsAgents(t *testing.T) {
+	dir := t.TempDir()
+	a := agent.Instance{PID: 1, WorkDir: dir}
+	a.Tokens.TotalTokens = 300
+	b := agent.Instance{PID: 2, WorkDir: dir}
+	b.Tokens.TotalTokens = 200
+
+	sections, _ := parseSections("groups")
+	doc := buildJSONDocument(collector.State{Agents: []agent.Instance{a, b}}, sections)
+	if len(doc.Groups) != 1 || doc.Groups[0].Agents != 2 || doc.Groups[0].TotalTokens != 500 {
+		t.Fatalf("expected one group with both agents, got %+v", doc.Groups)
+	}
+
+	sections, _ = parseSections("tokens")
+	if doc := buildJSONDocument(collector.State{Agents: []agent.Instance{a}}, sections); doc.Groups != nil {
+		t.Fatalf("expected groups to be dropped")
+	}
+}
+
+func TestDropDaemonSections(t *testing.T) {
+	sections, _ := parseSections("")
+	if err := dropDaemonSections(sections, false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sections["files"] || sections["lifecycle"] || !sections["tokens"] {
+		t.Fatalf("expected only files and lifecycle to be dropped, got %v", sections)
+	}
+
+	sections, _ = parseSections("tokens,files")
+	if err := dropDaemonSections(sections, true); err == nil {
+		t.Fatal("expected an error when files is asked for without a daemon")
+	}
+
+	sections, _ = parseSections("tokens,git")
+	if err := dropDaemonSections(sections, true); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
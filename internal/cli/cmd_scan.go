@@ -11,9 +11,14 @@ import (
 func runScan() error {
 	runtime := newScanRuntime()
 
-	agents, err := runtime.scan()
-	if err != nil {
-		return err
+	st, attached := attachDaemon()
+	agents := st.Agents
+	if !attached {
+		var err error
+		agents, err = runtime.scan()
+		if err != nil {
+			return err
+		}
 	}
 
 	if len(agents) == 0 {
@@ -25,8 +30,10 @@ func runScan() error {
 		return nil
 	}
 
-	collectTokenMetrics(agents)
-	collectGitAndSessionMetrics(agents)
+	if !attached {
+		collectTokenMetrics(agents)
+		collectGitAndSessionMetrics(agents)
+	}
 
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
 	fmt.Fprintf(w, "AGENT\tSTATUS\tPID\tCPU%%\tMEMORY\tTOKENS\tCOST\tREQS\tMODEL\tBRANCH\tDIRECTORY\n")
@@ -0,0 +1,306 @@
+package cli
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+	"github.com/Rafiki81/libagentmetrics/config"
+	"github.com/Rafiki81/libagentmetrics/monitor"
+	"github.com/rafaelperezbeato/agentmetrics/internal/appconfig"
+	"github.com/rafaelperezbeato/agentmetrics/internal/rules"
+	"github.com/rafaelperezbeato/agentmetrics/internal/suppress"
+)
+
+// expectMarker introduces the expected result at the end of a corpus line
+const expectMarker = " # expect:"
+
+func runSecurity(args []string) error {
+	if len(args) == 0 || args[0] != "test" {
+		return fmt.Errorf("usage: agentmetrics security test [flags] \"<command>\" | --file path | --corpus file")
+	}
+
+	fs := flag.NewFlagSet("security test", flag.ContinueOnError)
+	file := fs.String("file", "", "test a file path as a file operation")
+	corpus := fs.String("corpus", "", "test every line of a file")
+	workDir := fs.String("workdir", "", "working directory for {workdir} and workdir suppressions (default: current directory)")
+	agentID := fs.String("agent", "test", "agent ID for agent-specific rules and suppressions")
+	configPath := fs.String("config", "", "config file to test (default: the active config)")
+	command, err := parseInterspersed(fs, args[1:])
+	if err != nil {
+		return err
+	}
+
+	// Testing against defaults a broken config fell back to would report
+	// rules that never run, so config errors stop the test
+	// A candidate file supplies the built-in categories' settings as well
+	// as custom rules and suppressions
+	cfg := config.Load()
+	var appCfg *appconfig.Config
+	if *configPath != "" {
+		path := appconfig.ExpandHome(*configPath)
+		if cfg, err = appconfig.LoadLibraryFrom(path); err != nil {
+			return fmt.Errorf("loading config: %w", err)
+		}
+		appCfg, err = appconfig.LoadFrom(path)
+	} else {
+		appCfg, err = appconfig.Read()
+	}
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+
+	if *workDir == "" {
+		*workDir, _ = os.Getwd()
+	}
+	tester := newPolicyTester(cfg, appCfg, *agentID, *workDir)
+	for _, err := range tester.custom.Errors() {
+		fmt.Fprintf(os.Stderr, "Warning: skipping custom security %v\n", err)
+	}
+
+	switch {
+	case *corpus != "":
+		f, err := os.Open(*corpus)
+		if err != nil {
+			return fmt.Errorf("opening corpus: %w", err)
+		}
+		defer f.Close()
+		return runCorpus(os.Stdout, tester, f)
+	case *file != "":
+		printPolicyResult(os.Stdout, tester.test(rules.TargetFile, *file))
+		return nil
+	case len(command) > 0:
+		printPolicyResult(os.Stdout, tester.test(rules.TargetCommand, strings.Join(command, " ")))
+		return nil
+	default:
+		return fmt.Errorf("nothing to test: pass a command, --file or --corpus")
+	}
+}
+
+// parseInterspersed parses flags before and after positional arguments,
+// so "security test "ssh prod" --agent aider" sees --agent. Everything
+// after "--" is positional, for commands with dashes of their own.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var rest []string
+	if i := slices.Index(args, "--"); i >= 0 {
+		args, rest = args[:i], args[i+1:]
+	}
+
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return append(positional, rest...), nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// policyHit is one rule firing on the tested input
+type policyHit struct {
+	Severity     agent.SecuritySeverity
+	Category     string
+	Description  string
+	Rule         string
+	Blocked      bool
+	SuppressedBy string
+}
+
+// policyResult is everything that fired for one input
+type policyResult struct {
+	Target  string
+	Subject string
+	Hits    []policyHit
+}
+
+// highest returns the highest unsuppressed severity, or "none"
+func (r policyResult) highest() string {
+	best := "none"
+	rank := map[string]int{"none": 0, "LOW": 1, "MEDIUM": 2, "HIGH": 3, "CRITICAL": 4}
+	for _, h := range r.Hits {
+		if h.SuppressedBy == "" && rank[string(h.Severity)] > rank[best] {
+			best = string(h.Severity)
+		}
+	}
+	return best
+}
+
+// policyTester runs the configured rule set against sample input offline
+type policyTester struct {
+	custom  *rules.Engine
+	filter  *suppress.Filter
+	agentID string
+	workDir string
+	// builtin evaluates the library's categories
+	builtin func(target, subject string) []agent.SecurityEvent
+}
+
+func newPolicyTester(cfg *config.Config, appCfg *appconfig.Config, agentID, workDir string) *policyTester {
+	p := &policyTester{
+		custom:  rules.New(appCfg.Security.CustomRules),
+		filter:  suppress.New(appCfg.Security.Suppressions),
+		agentID: agentID,
+		workDir: workDir,
+	}
+	p.builtin = func(target, subject string) []agent.SecurityEvent {
+		// A fresh monitor per input so dedup and cooldowns don't hide repeats
+		mon := monitor.NewSecurityMonitor(cfg.Security)
+		a := agent.Instance{PID: os.Getpid(), WorkDir: workDir}
+		a.Info.ID, a.Info.Name = agentID, agentID
+
+		now := time.Now()
+		switch target {
+		case rules.TargetCommand:
+			a.Terminal.RecentCommands = []agent.TerminalCommand{{Command: subject, Timestamp: now}}
+			a.Terminal.TotalCommands = 1
+		case rules.TargetFile:
+			a.FileOps = []agent.FileOperation{{Path: subject, Op: "MODIFY", Timestamp: now}}
+		}
+		mon.CheckAgent(&a)
+		return mon.GetRecentEvents(60)
+	}
+	return p
+}
+
+// test evaluates built-in categories, custom rules and suppressions
+func (p *policyTester) test(target, subject string) policyResult {
+	res := policyResult{Target: target, Subject: subject}
+	now := time.Now()
+
+	var events []agent.SecurityEvent
+	var names []string
+	for _, evt := range p.builtin(target, subject) {
+		events = append(events, evt)
+		names = append(names, "")
+	}
+	for _, m := range p.custom.Match(target, subject, "MODIFY", p.workDir) {
+		events = append(events, agent.SecurityEvent{
+			Timestamp:   now,
+			Severity:    m.Severity,
+			Category:    rules.Category,
+			Description: m.Description,
+			Detail:      subject,
+			AgentID:     p.agentID,
+			Blocked:     m.Block,
+		})
+		names = append(names, m.Rule)
+	}
+
+	for i, evt := range events {
+		hit := policyHit{
+			Severity:    evt.Severity,
+			Category:    string(evt.Category),
+			Description: evt.Description,
+			Rule:        names[i],
+			Blocked:     evt.Blocked,
+		}
+		// Suppressions see the event as the collector would
+		evt.AgentID = p.agentID
+		if name, ok := p.filter.Rule(evt, []string{p.workDir}, now); ok {
+			hit.SuppressedBy = name
+		}
+		res.Hits = append(res.Hits, hit)
+	}
+	return res
+}
+
+// printPolicyResult lists every hit for one input
+func printPolicyResult(out io.Writer, res policyResult) {
+	fmt.Fprintf(out, "%s: %s\n", res.Target, res.Subject)
+	if len(res.Hits) == 0 {
+		fmt.Fprintln(out, "  ✅ no rules match")
+		return
+	}
+	for _, h := range res.Hits {
+		desc := h.Description
+		if h.Rule != "" && h.Rule != h.Description {
+			desc += fmt.Sprintf(" (rule %q)", h.Rule)
+		}
+		fmt.Fprintf(out, "  [%-8s] %-20s %s", h.Severity, h.Category, desc)
+		if h.Blocked {
+			fmt.Fprint(out, " [BLOCKED]")
+		}
+		if h.SuppressedBy != "" {
+			fmt.Fprintf(out, "  → suppressed by %q", h.SuppressedBy)
+		}
+		fmt.Fprintln(out)
+	}
+}
+
+// corpusLine is one input of a corpus file. Lines starting with "file:"
+// test a path; anything else is a command. An optional trailing
+// "# expect: HIGH" (or "none") asserts the highest unsuppressed severity.
+type corpusLine struct {
+	target  string
+	subject string
+	expect  string
+}
+
+func parseCorpusLine(line string) (corpusLine, bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return corpusLine{}, false
+	}
+
+	var c corpusLine
+	if i := strings.LastIndex(line, expectMarker); i >= 0 {
+		c.expect = strings.TrimSpace(line[i+len(expectMarker):])
+		if !strings.EqualFold(c.expect, "none") {
+			c.expect = strings.ToUpper(c.expect)
+		} else {
+			c.expect = "none"
+		}
+		line = strings.TrimSpace(line[:i])
+	}
+
+	c.target, c.subject = rules.TargetCommand, line
+	if rest, ok := strings.CutPrefix(line, "file:"); ok {
+		c.target, c.subject = rules.TargetFile, strings.TrimSpace(rest)
+	}
+	return c, true
+}
+
+// runCorpus tests every line and fails if any expectation is not met
+func runCorpus(out io.Writer, tester *policyTester, r io.Reader) error {
+	total, matched, checked, failed := 0, 0, 0, 0
+
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line, ok := parseCorpusLine(sc.Text())
+		if !ok {
+			continue
+		}
+		total++
+
+		res := tester.test(line.target, line.subject)
+		printPolicyResult(out, res)
+		if res.highest() != "none" {
+			matched++
+		}
+		if line.expect != "" {
+			checked++
+			if got := res.highest(); got != line.expect {
+				failed++
+				fmt.Fprintf(out, "  ❌ expected %s, got %s\n", line.expect, got)
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("reading corpus: %w", err)
+	}
+
+	fmt.Fprintf(out, "\n%d input(s), %d matched, %d/%d expectation(s) met\n", total, matched, checked-failed, checked)
+	if failed > 0 {
+		return fmt.Errorf("%d expectation(s) failed", failed)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"bytes"
+	"flag"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+	"github.com/rafaelperezbeato/agentmetrics/internal/appconfig"
+	"github.com/rafaelperezbeato/agentmetrics/internal/rules"
+	"github.com/rafaelperezbeato/agentmetrics/internal/suppress"
+)
+
+func testPolicyTester() *policyTester {
+	return &policyTester{
+		custom: rules.New([]appconfig.SecurityRule{
+			{Name: "terraform", Target: "command", Regex: "terraform apply", Severity: "high"},
+			{Name: "keys", Target: "file", Glob: "**/*.pem", Severity: "critical"},
+		}),
+		filter: suppress.New([]appconfig.Suppression{
+			{Name: "staging", Pattern: "staging"},
+		}),
+		agentID: "test",
+		workDir: "/src",
+		builtin: func(target, subject string) []agent.SecurityEvent {
+			if strings.HasPrefix(subject, "ssh ") {
+				return []agent.SecurityEvent{{Severity: agent.SecSevHigh, Category: "remote_access", Description: "Remote access"}}
+			}
+			return nil
+		},
+	}
+}
+
+func TestPolicyTesterReportsHitsAndSuppressions(t *testing.T) {
+	p := testPolicyTester()
+
+	res := p.test(rules.TargetCommand, "terraform apply -target=staging")
+	if len(res.Hits) != 1 || res.Hits[0].Rule != "terraform" || res.Hits[0].SuppressedBy != "staging" {
+		t.Fatalf("expected suppressed terraform hit, got %+v", res.Hits)
+	}
+	if res.highest() != "none" {
+		t.Fatalf("suppressed hits must not count, got %s", res.highest())
+	}
+
+	res = p.test(rules.TargetCommand, "ssh prod")
+	if res.highest() != "HIGH" || res.Hits[0].Category != "remote_access" {
+		t.Fatalf("expected built-in HIGH hit, got %+v", res.Hits)
+	}
+}
+
+func TestParseCorpusLine(t *testing.T) {
+	if _, ok := parseCorpusLine("  # comment"); ok {
+		t.Fatalf("expected comments to be skipped")
+	}
+
+	c, ok := parseCorpusLine("file: /src/certs/a.pem # expect: critical")
+	if !ok || c.target != rules.TargetFile || c.subject != "/src/certs/a.pem" || c.expect != "CRITICAL" {
+		t.Fatalf("unexpected line: %+v", c)
+	}
+
+	c, _ = parseCorpusLine("echo '#1' # expect: None")
+	if c.subject != "echo '#1'" || c.expect != "none" {
+		t.Fatalf("unexpected line: %+v", c)
+	}
+}
+
+func TestRunCorpusFailsOnUnmetExpectation(t *testing.T) {
+	corpus := strings.Join([]string{
+		"ssh prod # expect: HIGH",
+		"file: /src/a.pem # expect: CRITICAL",
+		"ls -la # expect: none",
+		"terraform apply # expect: none",
+	}, "\n")
+
+	var out bytes.Buffer
+	err := runCorpus(&out, testPolicyTester(), strings.NewReader(corpus))
+	if err == nil {
+		t.Fatalf("expected failure for terraform line, got:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "3/4 expectation(s) met") {
+		t.Fatalf("unexpected summary:\n%s", out.String())
+	}
+}
+
+func TestParseInterspersedReadsFlagsAfterTheCommand(t *testing.T) {
+	tests := []struct {
+		args    []string
+		want    []string
+		agentID string
+	}{
+		{[]string{"--agent", "aider", "ssh prod"}, []string{"ssh prod"}, "aider"},
+		{[]string{"ssh prod", "--agent", "aider"}, []string{"ssh prod"}, "aider"},
+		{[]string{"ssh", "--agent=aider", "prod"}, []string{"ssh", "prod"}, "aider"},
+		{[]string{"--agent", "aider", "--", "rm", "-rf", "/"}, []string{"rm", "-rf", "/"}, "aider"},
+		{[]string{}, nil, "test"},
+	}
+	for _, tt := range tests {
+		fs := flag.NewFlagSet("security test", flag.ContinueOnError)
+		agentID := fs.String("agent", "test", "")
+		got, err := parseInterspersed(fs, tt.args)
+		if err != nil {
+			t.Fatalf("parsing %q: %v", tt.args, err)
+		}
+		if !slices.Equal(got, tt.want) || *agentID != tt.agentID {
+			t.Errorf("parseInterspersed(%q) = %q, agent %q; want %q, agent %q", tt.args, got, *agentID, tt.want, tt.agentID)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Rafiki81/libagentmetrics/config"
+	"github.com/rafaelperezbeato/agentmetrics/internal/api"
+	"github.com/rafaelperezbeato/agentmetrics/internal/appconfig"
+	"github.com/rafaelperezbeato/agentmetrics/internal/collector"
+)
+
+const defaultServeAddr = "127.0.0.1:8787"
+
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := fs.String("addr", defaultServeAddr, "listen address")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg := config.Load()
+	interval := cfg.RefreshInterval.Duration()
+	if interval <= 0 {
+		interval = 3 * time.Second
+	}
+
+	// Like the daemon, serve acts unattended, so a broken config stops it
+	// instead of running on defaults
+	appCfg, err := appconfig.Read()
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	warnInvalidRules(appCfg)
+
+	// Prefer a running daemon; otherwise run the pipeline in-process
+	source, stopSource := newLiveSource(cfg, appCfg, collector.Act)
+	defer stopSource()
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           api.NewServer(collector.NewCache(source, interval)),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Requests still in flight collect and publish, so the source and its
+	// sinks are stopped only once Shutdown has drained them
+	drained := make(chan struct{})
+	go func() {
+		defer close(drained)
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Printf("AgentMetrics API listening on http://%s (Ctrl+C to stop)\n", *addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("serving API: %w", err)
+	}
+	<-drained
+	return nil
+}
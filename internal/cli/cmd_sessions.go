@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Rafiki81/libagentmetrics/config"
+	"github.com/Rafiki81/libagentmetrics/monitor"
+	"github.com/rafaelperezbeato/agentmetrics/internal/sessions"
+)
+
+// newSessionStore opens the reports directory inside the history dir
+func newSessionStore(cfg *config.Config) *sessions.Store {
+	history := monitor.NewHistoryStore(cfg.Export.Directory, cfg.Export.MaxHistory)
+	return sessions.NewStore(sessions.DirFor(history.DataDir()))
+}
+
+func runSessions(args []string) error {
+	store := newSessionStore(config.Load())
+
+	if len(args) > 0 {
+		switch args[0] {
+		case "list", "ls":
+		case "show":
+			if len(args) < 2 {
+				return fmt.Errorf("usage: agentmetrics sessions show <id>")
+			}
+			r, err := store.Load(args[1])
+			if err != nil {
+				return err
+			}
+			printSessionReport(r)
+			return nil
+		default:
+			return fmt.Errorf("unknown sessions command: %s (use 'list' or 'show <id>')", args[0])
+		}
+	}
+
+	reports, err := store.List()
+	if err != nil {
+		return err
+	}
+	if len(reports) == 0 {
+		fmt.Printf("No session reports yet in %s\n", store.Dir())
+		fmt.Println("Reports are written by the TUI or daemon when an agent process exits.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "ENDED\tAGENT\tPID\tDURATION\tTOKENS\tCOST\tLOC\tDIRECTORY\tID\n")
+	fmt.Fprintf(w, "-----\t-----\t---\t--------\t------\t----\t---\t---------\t--\n")
+	for _, r := range reports {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\t+%d/-%d\t%s\t%s\n",
+			r.EndedAt.Format("2006-01-02 15:04"),
+			r.AgentName,
+			r.PID,
+			monitor.FormatDuration(secs(r.DurationSecs)),
+			monitor.FormatTokenCount(r.TotalTokens),
+			monitor.FormatCost(r.Cost),
+			r.LOCAdded,
+			r.LOCRemoved,
+			r.WorkDir,
+			r.ID,
+		)
+	}
+	w.Flush()
+	return nil
+}
+
+// printSessionReport renders one report in full
+func printSessionReport(r sessions.Report) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Session:\t%s\n", r.ID)
+	fmt.Fprintf(w, "Agent:\t%s (PID %d)\n", r.AgentName, r.PID)
+	fmt.Fprintf(w, "Directory:\t%s\n", r.WorkDir)
+	if r.Branch != "" {
+		fmt.Fprintf(w, "Branch:\t%s\n", r.Branch)
+	}
+	if r.Model != "" {
+		fmt.Fprintf(w, "Model:\t%s\n", r.Model)
+	}
+	fmt.Fprintf(w, "Started:\t%s\n", r.StartedAt.Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(w, "Ended:\t%s\n", r.EndedAt.Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(w, "Duration:\t%s (active %s, idle %s)\n",
+		monitor.FormatDuration(secs(r.DurationSecs)),
+		monitor.FormatDuration(secs(r.ActiveSecs)),
+		monitor.FormatDuration(secs(r.IdleSecs)),
+	)
+	fmt.Fprintf(w, "Tokens:\t%s (in %s, out %s, %d requests)\n",
+		monitor.FormatTokenCount(r.TotalTokens),
+		monitor.FormatTokenCount(r.InputTokens),
+		monitor.FormatTokenCount(r.OutputTokens),
+		r.Requests,
+	)
+	fmt.Fprintf(w, "Cost:\t%s\n", monitor.FormatCost(r.Cost))
+	fmt.Fprintf(w, "Lines:\t+%d/-%d\n", r.LOCAdded, r.LOCRemoved)
+	fmt.Fprintf(w, "Commits:\t%d\n", r.Commits)
+	fmt.Fprintf(w, "Commands:\t%d\n", r.Commands)
+	fmt.Fprintf(w, "Security events:\t%d%s\n", r.SecurityEventCount(), formatCounts(r.SecurityEvents))
+	w.Flush()
+
+	if len(r.FilesTouched) > 0 {
+		fmt.Printf("\nFiles touched (%d):\n", len(r.FilesTouched))
+		fmt.Println("  " + strings.Join(r.FilesTouched, "\n  "))
+	}
+}
+
+func secs(n int64) time.Duration {
+	return time.Duration(n) * time.Second
+}
@@ -1,57 +1,159 @@
 package cli
 
 import (
+	"context"
+	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/Rafiki81/libagentmetrics/agent"
+	"github.com/Rafiki81/libagentmetrics/config"
+	"github.com/Rafiki81/libagentmetrics/monitor"
+	"github.com/rafaelperezbeato/agentmetrics/internal/appconfig"
+	"github.com/rafaelperezbeato/agentmetrics/internal/collector"
 )
 
-func runWatch() {
-	runtime := newScanRuntime()
+func runWatch(args []string) error {
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	format := fs.String("format", "text", "output format: text or ndjson")
+	interval := fs.Duration("interval", 0, "refresh interval (default: refresh_interval from config)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	cfg := config.Load()
+	if *interval <= 0 {
+		*interval = cfg.RefreshInterval.Duration()
+	}
+	if *interval <= 0 {
+		*interval = 3 * time.Second
+	}
 
-	fmt.Println("AgentMetrics - Watch mode (Ctrl+C to exit)")
-	fmt.Println(strings.Repeat("-", 60))
+	stats := newWatchStats(time.Now())
+
+	var render func(collector.State) error
+	switch *format {
+	case "text":
+		fmt.Println("AgentMetrics - Watch mode (Ctrl+C to exit)")
+		fmt.Println(strings.Repeat("-", 60))
+		render = func(st collector.State) error {
+			renderWatchText(os.Stdout, st, stats.observe(st), *interval)
+			return nil
+		}
+	case "ndjson":
+		enc := json.NewEncoder(os.Stdout)
+		render = func(st collector.State) error {
+			stats.observe(st)
+			return enc.Encode(st)
+		}
+	default:
+		return fmt.Errorf("unknown format: %s (use 'text' or 'ndjson')", *format)
+	}
+
+	source, stopSource := newLiveSource(cfg, appconfig.Load(), collector.ReportOnly)
+	defer stopSource()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err := watchLoop(ctx, source, *interval, render)
+
+	// Keep stdout clean for NDJSON consumers
+	out := io.Writer(os.Stdout)
+	if *format == "ndjson" {
+		out = os.Stderr
+	}
+	fmt.Fprintln(out)
+	stats.writeSummary(out, time.Now())
+	return err
+}
+
+// watchLoop renders the source's state once per interval until ctx ends
+func watchLoop(ctx context.Context, source collector.Source, interval time.Duration, render func(collector.State) error) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 
 	for {
-		agents, err := runtime.scan()
+		st, err := source.State()
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			time.Sleep(runtime.cfg.RefreshInterval.Duration())
-			continue
+		} else if err := render(st); err != nil {
+			// Downstream pipe closed (e.g. `| head`): stop quietly
+			return nil
 		}
 
-		fmt.Print("\033[H\033[2J")
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
 
-		fmt.Printf("AgentMetrics - %s\n", time.Now().Format("15:04:05"))
-		fmt.Println(strings.Repeat("-", 60))
+// renderWatchText redraws the human-readable watch screen
+func renderWatchText(w io.Writer, st collector.State, iv watchInterval, interval time.Duration) {
+	fmt.Fprint(w, "\033[H\033[2J")
 
-		if len(agents) == 0 {
-			fmt.Println("  No active agents...")
-		} else {
-			for _, a := range agents {
-				statusIcon := "o"
-				switch a.Status {
-				case agent.StatusRunning:
-					statusIcon = "\033[32m*\033[0m"
-				case agent.StatusIdle:
-					statusIcon = "\033[33m*\033[0m"
-				case agent.StatusStopped:
-					statusIcon = "\033[31m*\033[0m"
-				}
+	fmt.Fprintf(w, "AgentMetrics - %s\n", st.Timestamp.Format("15:04:05"))
+	fmt.Fprintln(w, strings.Repeat("-", 60))
+
+	if len(st.Agents) == 0 {
+		fmt.Fprintln(w, "  No active agents...")
+	} else {
+		for _, a := range st.Agents {
+			statusIcon := "o"
+			switch a.Status {
+			case agent.StatusRunning:
+				statusIcon = "\033[32m*\033[0m"
+			case agent.StatusIdle:
+				statusIcon = "\033[33m*\033[0m"
+			case agent.StatusStopped:
+				statusIcon = "\033[31m*\033[0m"
+			}
 
-				fmt.Printf("  %s %-20s PID:%-6d CPU:%.1f%%  MEM:%.1fMB\n",
-					statusIcon, a.Info.Name, a.PID, a.CPU, a.Memory,
+			fmt.Fprintf(w, "  %s %-20s PID:%-6d CPU:%.1f%%  MEM:%.1fMB\n",
+				statusIcon, a.Info.Name, a.PID, a.CPU, a.Memory,
+			)
+			if a.Tokens.TotalTokens > 0 || a.Tokens.RequestCount > 0 {
+				fmt.Fprintf(w, "    Tokens: %s  Cost: %s",
+					monitor.FormatTokenCount(a.Tokens.TotalTokens),
+					monitor.FormatCost(a.Tokens.EstCost),
 				)
-				if a.WorkDir != "" {
-					fmt.Printf("    -> %s\n", a.WorkDir)
+				if d, ok := iv.Deltas[a.PID]; ok {
+					fmt.Fprintf(w, "  │  +%s tokens  +%s  (%s/h)",
+						monitor.FormatTokenCount(d.Tokens),
+						monitor.FormatCost(d.Cost),
+						monitor.FormatCost(d.BurnRate),
+					)
 				}
+				fmt.Fprintln(w)
+			}
+			if a.WorkDir != "" {
+				fmt.Fprintf(w, "    -> %s\n", a.WorkDir)
 			}
 		}
+	}
 
-		fmt.Printf("\n  Next scan in %s...\n", runtime.cfg.RefreshInterval.Duration())
-		time.Sleep(runtime.cfg.RefreshInterval.Duration())
+	if len(iv.Alerts) > 0 {
+		fmt.Fprintln(w, "\n  Alerts this interval:")
+		for _, al := range iv.Alerts {
+			fmt.Fprintf(w, "    %s [%s] %s — %s\n",
+				al.Timestamp.Format("15:04:05"), al.Level, al.AgentName, al.Message)
+		}
 	}
+	if len(iv.SecurityEvents) > 0 {
+		fmt.Fprintln(w, "\n  Security events this interval:")
+		for _, evt := range iv.SecurityEvents {
+			fmt.Fprintf(w, "    %s [%s] %s — %s\n",
+				evt.Timestamp.Format("15:04:05"), evt.Severity, evt.Description, evt.Detail)
+		}
+	}
+
+	fmt.Fprintf(w, "\n  Next scan in %s...\n", interval)
 }
@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+	"github.com/rafaelperezbeato/agentmetrics/internal/collector"
+)
+
+func TestRunWatchRejectsUnknownFormat(t *testing.T) {
+	if err := runWatch([]string{"--format", "xml"}); err == nil {
+		t.Fatalf("expected error for unknown format")
+	}
+}
+
+func TestWatchLoopEmitsOneNDJSONLinePerInterval(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	renders := 0
+	render := func(st collector.State) error {
+		renders++
+		if renders == 2 {
+			cancel()
+		}
+		return enc.Encode(st)
+	}
+
+	source := fakeSource{state: collector.State{Agents: []agent.Instance{{PID: 11}}}}
+	if err := watchLoop(ctx, source, time.Millisecond, render); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 NDJSON lines, got %d: %q", len(lines), buf.String())
+	}
+	var st collector.State
+	if err := json.Unmarshal([]byte(lines[0]), &st); err != nil {
+		t.Fatalf("expected valid JSON line: %v", err)
+	}
+	if len(st.Agents) != 1 || st.Agents[0].PID != 11 {
+		t.Fatalf("expected agent PID 11, got %+v", st.Agents)
+	}
+}
@@ -7,12 +7,24 @@ import (
 	"os/exec"
 
 	"github.com/Rafiki81/libagentmetrics/config"
+	"github.com/rafaelperezbeato/agentmetrics/internal/appconfig"
 	"github.com/rafaelperezbeato/agentmetrics/internal/tui"
 )
 
 func runTUI() error {
 	cfg := config.Load()
-	return tui.StartApp(cfg)
+	// A broken config is shown in the TUI instead of on the hidden stderr
+	appCfg, configErr := appconfig.Read()
+
+	// Attach to a running daemon instead of collecting twice; it publishes
+	// to the sinks itself
+	remote, _ := dialDaemon()
+	if remote != nil {
+		return tui.StartApp(cfg, appCfg, remote, nil, configErr)
+	}
+	sinks, closeSinks := newSinks(cfg, appCfg)
+	defer closeSinks()
+	return tui.StartApp(cfg, appCfg, nil, sinks, configErr)
 }
 
 func runConfig(args []string) error {
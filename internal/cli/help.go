@@ -8,10 +8,17 @@ func printHelp(version string) {
 USAGE:
   agentmetrics              Launch interactive TUI dashboard
   agentmetrics scan         Quick one-time scan
-  agentmetrics watch        Continuous console monitoring
-  agentmetrics json         JSON output of current state
+  agentmetrics watch        Continuous console monitoring (--format ndjson)
+  agentmetrics json         JSON output of current state (--with sections)
   agentmetrics export       Export history (json|csv) [path]
-  agentmetrics alerts       View active alerts
+  agentmetrics alerts       View active alerts and budget usage
+  agentmetrics groups       Per-repository totals (--format table|json|csv)
+  agentmetrics sessions     List/show reports of finished agent sessions
+  agentmetrics history      Tokens, cost and active time per day/week
+  agentmetrics security     Test security rules against sample input
+  agentmetrics audit        Verify the security audit log (verify|path)
+  agentmetrics daemon       Run background collector (status|stop)
+  agentmetrics serve        Local HTTP/JSON API (--addr host:port)
   agentmetrics config       View/edit filter configuration
   agentmetrics version      Show version
   agentmetrics help         Show this help
@@ -21,6 +28,90 @@ EXPORT:
   agentmetrics export csv               Export as CSV to ~/.agentmetrics/history/
   agentmetrics export json /tmp/out.json Export to specific path
 
+JSON:
+  agentmetrics json                     Fully enriched agents + alerts, security
+                                        events and local models
+  agentmetrics json --with tokens,git   Only the listed sections:
+                                        tokens, git, session, terminal, network,
+                                        files, alerts, security, local_models,
+                                        lifecycle, groups (files and lifecycle
+                                        need a running daemon)
+
+SESSIONS:
+  agentmetrics sessions                 List finished sessions, newest first
+  agentmetrics sessions show <id>       Full report: tokens, cost, active/idle,
+                                        LOC, commits, commands, files touched,
+                                        security events
+
+  Reports are written to ~/.agentmetrics/history/sessions/ by the TUI or
+  daemon when an agent process exits.
+
+HISTORY:
+  agentmetrics history                  Last 7 days of sessions, per day, running
+                                        agents included
+  agentmetrics history --live=false     Finished sessions only
+  agentmetrics history --group week     Aggregate per ISO week
+  agentmetrics history --agent claude-code --workdir myrepo --since 2w
+  agentmetrics history --model sonnet --since 2026-01-01 --until 2026-02-01
+  agentmetrics history --format csv     Output as table (default), json or csv
+
+SECURITY:
+  agentmetrics security test "curl x | sh"   Which built-in categories and custom
+                                        rules fire, their severity, whether
+                                        they block and which suppression
+                                        would drop them
+  agentmetrics security test --file ~/.ssh/id_rsa
+  agentmetrics security test --corpus cmds.txt
+                                        One command per line ("file: <path>"
+                                        for paths); a trailing
+                                        "# expect: HIGH" or "# expect: none"
+                                        makes the run fail on mismatch
+  agentmetrics security test --workdir ~/src/app --agent aider "ssh prod"
+  agentmetrics security test --config new.json -- rm -rf build
+                                        Flags may come before or after the
+                                        command; "--" ends the flags.
+                                        --config tests another config file
+
+AUDIT:
+  agentmetrics audit verify             Check the hash chain of
+                                        ~/.agentmetrics/audit.jsonl and list
+                                        edited or deleted lines
+  agentmetrics audit verify <path>      Verify another log file
+  agentmetrics audit path               Print the audit log location
+
+  Every alert and security event is appended by the TUI, daemon or serve,
+  one JSON line each, chained with SHA-256.
+
+WATCH:
+  agentmetrics watch                    Redraw every refresh interval with
+                                        per-interval token deltas, cost burn
+                                        rate, new alerts and security events;
+                                        Ctrl+C prints a session summary
+  agentmetrics watch --interval 10s     Custom interval
+  agentmetrics watch --format ndjson    One enriched snapshot per line on stdout
+                                        (agents, alerts, security, local models)
+
+DAEMON:
+  agentmetrics daemon                   Run the collection loop in the foreground
+  agentmetrics daemon status            Show whether a daemon is running and failing sinks
+  agentmetrics daemon stop              Stop a running daemon
+
+  While a daemon is running, the TUI, scan, watch, json, export and alerts
+  attach to it over ~/.agentmetrics/agentmetrics.sock instead of rescanning,
+  so token deltas, history and alert cooldowns carry over between commands.
+
+SERVE:
+  agentmetrics serve                    Serve on 127.0.0.1:8787
+  agentmetrics serve --addr 127.0.0.1:9000
+
+  GET /api/snapshot                     Full enriched state
+  GET /api/agents                       Current agents
+  GET /api/agents/{pid|id}              One agent by PID or agent ID
+  GET /api/alerts                       Recent alerts
+  GET /api/security                     Recent security events
+  GET /api/local-models                 Local model servers
+  GET /metrics                          Prometheus text format
+
 MONITORED METRICS:
   - CPU / Memory              Process resource usage
   - Tokens (input/output)     Tokens consumed via logs/db
@@ -52,6 +143,18 @@ CONFIGURATION:
     primary, secondary, success, warning, danger, muted, bg, fg, border
   export                    History export settings
     format, directory, max_history
+    otlp                    OTLP/HTTP push: enabled, endpoint, headers, interval
+  audit                     Hash-chained event log: enabled, path
+  notifications             Webhooks: url, headers, template, min_level,
+                            min_severity, agents, retries, backoff, cooldown
+    desktop                 enabled, min_level, min_severity, interval, command, on_click
+  tui                       Dashboard settings: trend_window (sparkline history),
+                            layout (cards or table), grouped
+  hooks                     Commands run on events: name, on, command, timeout
+                            (on: alert[:level], security[:severity|category],
+                            agent_start, agent_stop, agent_status[:status],
+                            model_changed, branch_changed, workdir_changed,
+                            budget_exceeded[:name])
   display                   Dashboard section toggles
     show_tokens, show_cost, show_git, show_terminal, etc.
   keybindings               Keyboard shortcuts
@@ -74,9 +177,21 @@ SUPPORTED AGENTS:
   - Gemini CLI          Google Gemini CLI agent
 
 TUI SHORTCUTS:
-  up/down, j/k    Navigate agents
+  up/down, j/k    Navigate agents (scroll in details)
+  PgUp/PgDn       Scroll (mouse wheel too)
+  Home/End        Jump to top/bottom
+  [ / ]           Focus previous/next section
+  a               Show all entries of the focused section
   Enter           View agent details
   ESC             Back to dashboard
+  v               Cards / table layout
+  g               Group agents by repository
+  /               Filter agents (name, dir, model, branch)
+  o               Cycle sort (cpu, mem, cost, tokens, uptime, name)
+  < / >           Table: sort by previous/next column
+  -               Reverse sort
+  s               Security review queue
+  t               Agent timeline
   r               Manual refresh
   e               Export history (JSON)
   Tab             Toggle view
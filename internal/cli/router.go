@@ -21,9 +21,12 @@ func Run(args []string, version string) int {
 			return 1
 		}
 	case "watch", "w":
-		runWatch()
+		if err := runWatch(args[1:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
 	case "json":
-		if err := runJSON(); err != nil {
+		if err := runJSON(args[1:]); err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			return 1
 		}
@@ -37,6 +40,41 @@ func Run(args []string, version string) int {
 			fmt.Fprintf(os.Stderr, "Error scanning agents: %v\n", err)
 			return 1
 		}
+	case "daemon", "d":
+		if err := runDaemon(args[1:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	case "serve":
+		if err := runServe(args[1:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	case "sessions":
+		if err := runSessions(args[1:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	case "history":
+		if err := runHistory(args[1:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	case "security":
+		if err := runSecurity(args[1:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	case "groups":
+		if err := runGroups(args[1:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+	case "audit":
+		if err := runAudit(args[1:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
 	case "config", "c":
 		if err := runConfig(args[1:]); err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
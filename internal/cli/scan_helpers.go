@@ -4,6 +4,9 @@ import (
 	"github.com/Rafiki81/libagentmetrics/agent"
 	"github.com/Rafiki81/libagentmetrics/config"
 	"github.com/Rafiki81/libagentmetrics/monitor"
+	"github.com/rafaelperezbeato/agentmetrics/internal/appconfig"
+	"github.com/rafaelperezbeato/agentmetrics/internal/collector"
+	"github.com/rafaelperezbeato/agentmetrics/internal/daemon"
 )
 
 type scanRuntime struct {
@@ -36,6 +39,16 @@ var newSessionCollector = func() sessionCollector {
 	return monitor.NewSessionMonitor()
 }
 
+// dialDaemon connects to a running daemon; commands fall back to a local
+// scan when it fails.
+var dialDaemon = func() (collector.Source, error) {
+	client, err := daemon.Dial(daemon.SocketPath())
+	if err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
 func newScanRuntime() *scanRuntime {
 	cfg := config.Load()
 	registry := agent.NewRegistry()
@@ -52,6 +65,44 @@ func (r *scanRuntime) scan() ([]agent.Instance, error) {
 	return r.detector.Scan()
 }
 
+// attachDaemon returns the daemon's latest enriched state, if one is running
+func attachDaemon() (collector.State, bool) {
+	source, err := dialDaemon()
+	if err != nil {
+		return collector.State{}, false
+	}
+	st, err := source.State()
+	if err != nil {
+		return collector.State{}, false
+	}
+	return st, true
+}
+
+// newLiveSource returns a running daemon or, failing that, a local
+// collector whose monitors persist across calls. A local collector in
+// collector.Act mode also gets the exporters of newSinks; an attached
+// daemon acts and publishes on its own. Call stop when done.
+func newLiveSource(cfg *config.Config, appCfg *appconfig.Config, mode collector.Mode) (collector.Source, func()) {
+	if source, err := dialDaemon(); err == nil {
+		return source, func() {}
+	}
+
+	local := collector.New(cfg, appCfg, mode)
+	closeSinks := func() {}
+	if mode == collector.Act {
+		var sinks []collector.Sink
+		sinks, closeSinks = newSinks(cfg, appCfg)
+		for _, sink := range sinks {
+			local.AddSink(sink)
+		}
+	}
+	local.Start()
+	return local, func() {
+		local.Stop()
+		closeSinks()
+	}
+}
+
 func collectTokenMetrics(agents []agent.Instance) {
 	tokenMon := newTokenCollector()
 	tokenMon.Collect(agents)
@@ -1,9 +1,11 @@
 package cli
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/Rafiki81/libagentmetrics/agent"
+	"github.com/rafaelperezbeato/agentmetrics/internal/collector"
 )
 
 type fakeTokenCollector struct {
@@ -89,3 +91,43 @@ func TestCollectSessionMetricsCallsEachPerAgent(t *testing.T) {
 		t.Fatalf("expected session collector called %d times, got %d", len(agents), sessionFake.called)
 	}
 }
+
+type fakeSource struct {
+	state collector.State
+	err   error
+}
+
+func (f fakeSource) State() (collector.State, error) {
+	return f.state, f.err
+}
+
+func TestAttachDaemonUsesRunningDaemon(t *testing.T) {
+	original := dialDaemon
+	defer func() { dialDaemon = original }()
+
+	want := collector.State{Agents: []agent.Instance{{PID: 7}}}
+	dialDaemon = func() (collector.Source, error) { return fakeSource{state: want}, nil }
+
+	st, ok := attachDaemon()
+	if !ok {
+		t.Fatalf("expected to attach to daemon")
+	}
+	if len(st.Agents) != 1 || st.Agents[0].PID != 7 {
+		t.Fatalf("expected daemon agents, got %+v", st.Agents)
+	}
+}
+
+func TestAttachDaemonFallsBackWhenUnavailable(t *testing.T) {
+	original := dialDaemon
+	defer func() { dialDaemon = original }()
+
+	dialDaemon = func() (collector.Source, error) { return nil, errors.New("no daemon") }
+	if _, ok := attachDaemon(); ok {
+		t.Fatalf("expected fallback when daemon is unavailable")
+	}
+
+	dialDaemon = func() (collector.Source, error) { return fakeSource{err: errors.New("not ready")}, nil }
+	if _, ok := attachDaemon(); ok {
+		t.Fatalf("expected fallback when daemon has no state")
+	}
+}
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Rafiki81/libagentmetrics/config"
+	"github.com/rafaelperezbeato/agentmetrics/internal/appconfig"
+	"github.com/rafaelperezbeato/agentmetrics/internal/audit"
+	"github.com/rafaelperezbeato/agentmetrics/internal/collector"
+	"github.com/rafaelperezbeato/agentmetrics/internal/notify"
+	"github.com/rafaelperezbeato/agentmetrics/internal/otlp"
+)
+
+// newSinks builds the exporters enabled in the app config. Only
+// long-running commands that own a collector attach them, and call
+// closeSinks once the collector has stopped to flush queued deliveries.
+func newSinks(cfg *config.Config, appCfg *appconfig.Config) (sinks []collector.Sink, closeSinks func()) {
+	if appCfg.Export.OTLP.Enabled {
+		sinks = append(sinks, otlp.NewExporter(appCfg.Export.OTLP))
+	}
+	if appCfg.Audit.Enabled {
+		auditLog, err := audit.Open(auditPath(appCfg))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: audit log disabled: %v\n", err)
+		} else {
+			sinks = append(sinks, auditLog)
+		}
+	}
+	if hooks := appCfg.Notifications.Webhooks; len(hooks) > 0 {
+		cooldown := time.Duration(cfg.Alerts.CooldownMinutes) * time.Minute
+		webhooks := notify.NewWebhooks(hooks, cooldown)
+		for _, err := range webhooks.Errors() {
+			fmt.Fprintf(os.Stderr, "Warning: skipping %v\n", err)
+		}
+		sinks = append(sinks, webhooks)
+	}
+	if appCfg.Notifications.Desktop.Enabled {
+		sinks = append(sinks, notify.NewDesktop(appCfg.Notifications.Desktop))
+	}
+
+	closeSinks = func() {
+		for _, sink := range sinks {
+			if c, ok := sink.(interface{ Close() }); ok {
+				c.Close()
+			}
+		}
+	}
+	return sinks, closeSinks
+}
+
+// auditPath returns the configured audit log location
+func auditPath(appCfg *appconfig.Config) string {
+	if appCfg.Audit.Path != "" {
+		return appconfig.ExpandHome(appCfg.Audit.Path)
+	}
+	return audit.DefaultPath()
+}
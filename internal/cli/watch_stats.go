@@ -0,0 +1,151 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+	"github.com/Rafiki81/libagentmetrics/monitor"
+	"github.com/rafaelperezbeato/agentmetrics/internal/collector"
+)
+
+// agentDelta is one agent's token and cost change since the last interval
+type agentDelta struct {
+	Tokens int64
+	Cost   float64
+	// BurnRate is the cost rate over the interval in USD per hour
+	BurnRate float64
+}
+
+// watchInterval is what happened between two watch refreshes
+type watchInterval struct {
+	Deltas         map[int]agentDelta
+	Alerts         []agent.Alert
+	SecurityEvents []agent.SecurityEvent
+}
+
+// watchStats keeps cross-interval state for watch mode: per-PID token
+// baselines for deltas, a watermark for new events, and session totals.
+type watchStats struct {
+	started  time.Time
+	lastSeen time.Time
+	prev     map[int]agent.Instance
+	names    map[int]string
+
+	watermark collector.Watermark
+
+	tokens   int64
+	cost     float64
+	alerts   map[agent.AlertLevel]int
+	security map[agent.SecuritySeverity]int
+}
+
+func newWatchStats(now time.Time) *watchStats {
+	return &watchStats{
+		started:  now,
+		prev:     map[int]agent.Instance{},
+		names:    map[int]string{},
+		alerts:   map[agent.AlertLevel]int{},
+		security: map[agent.SecuritySeverity]int{},
+	}
+}
+
+// observe diffs st against the previous state. An agent's first sighting
+// only sets its baseline, so tokens spent before watch started don't count.
+func (w *watchStats) observe(st collector.State) watchInterval {
+	iv := watchInterval{Deltas: map[int]agentDelta{}}
+
+	elapsed := time.Duration(0)
+	if !w.lastSeen.IsZero() {
+		elapsed = st.Timestamp.Sub(w.lastSeen)
+	}
+	w.lastSeen = st.Timestamp
+
+	current := make(map[int]agent.Instance, len(st.Agents))
+	for _, a := range st.Agents {
+		current[a.PID] = a
+		w.names[a.PID] = a.Info.Name
+
+		prev, ok := w.prev[a.PID]
+		if !ok {
+			continue
+		}
+
+		d := agentDelta{
+			Tokens: a.Tokens.TotalTokens - prev.Tokens.TotalTokens,
+			Cost:   a.Tokens.EstCost - prev.Tokens.EstCost,
+		}
+		// Counters went backwards: the agent restarted its own accounting
+		if d.Tokens < 0 || d.Cost < 0 {
+			d.Tokens = a.Tokens.TotalTokens
+			d.Cost = a.Tokens.EstCost
+		}
+		if elapsed > 0 {
+			d.BurnRate = d.Cost / elapsed.Hours()
+		}
+
+		iv.Deltas[a.PID] = d
+		w.tokens += d.Tokens
+		w.cost += d.Cost
+	}
+	w.prev = current
+
+	iv.Alerts, iv.SecurityEvents = w.watermark.NewEvents(st)
+	for _, al := range iv.Alerts {
+		w.alerts[al.Level]++
+	}
+	for _, evt := range iv.SecurityEvents {
+		w.security[evt.Severity]++
+	}
+
+	return iv
+}
+
+// writeSummary prints the end-of-session report shown on Ctrl+C
+func (w *watchStats) writeSummary(out io.Writer, now time.Time) {
+	duration := now.Sub(w.started)
+
+	fmt.Fprintln(out, "AgentMetrics - Watch session summary")
+	fmt.Fprintf(out, "  Duration:        %s\n", monitor.FormatDuration(duration))
+	fmt.Fprintf(out, "  Agents seen:     %d\n", len(w.names))
+	fmt.Fprintf(out, "  Tokens consumed: %s\n", monitor.FormatTokenCount(w.tokens))
+	fmt.Fprintf(out, "  Cost:            %s", monitor.FormatCost(w.cost))
+	if duration > 0 {
+		fmt.Fprintf(out, " (%s/h)", monitor.FormatCost(w.cost/duration.Hours()))
+	}
+	fmt.Fprintln(out)
+
+	fmt.Fprintf(out, "  Alerts:          %d%s\n", sumCounts(w.alerts), formatCounts(w.alerts))
+	fmt.Fprintf(out, "  Security events: %d%s\n", sumCounts(w.security), formatCounts(w.security))
+}
+
+func sumCounts[K ~string](counts map[K]int) int {
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	return total
+}
+
+// formatCounts renders " (CRITICAL 1, WARNING 2)" in a stable order
+func formatCounts[K ~string](counts map[K]int) string {
+	if len(counts) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, string(k))
+	}
+	sort.Strings(keys)
+
+	s := " ("
+	for i, k := range keys {
+		if i > 0 {
+			s += ", "
+		}
+		s += fmt.Sprintf("%s %d", k, counts[K(k)])
+	}
+	return s + ")"
+}
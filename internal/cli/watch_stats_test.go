@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+	"github.com/rafaelperezbeato/agentmetrics/internal/collector"
+)
+
+func watchState(ts time.Time, tokens int64, cost float64) collector.State {
+	a := agent.Instance{PID: 10, Info: agent.Info{Name: "Claude Code"}}
+	a.Tokens.TotalTokens = tokens
+	a.Tokens.EstCost = cost
+	return collector.State{Timestamp: ts, Agents: []agent.Instance{a}}
+}
+
+func TestWatchStatsFirstSightingIsBaseline(t *testing.T) {
+	start := time.Now()
+	stats := newWatchStats(start)
+
+	iv := stats.observe(watchState(start, 10000, 1.0))
+	if len(iv.Deltas) != 0 {
+		t.Fatalf("expected no deltas on first sighting, got %+v", iv.Deltas)
+	}
+
+	iv = stats.observe(watchState(start.Add(time.Minute), 12500, 1.5))
+	d, ok := iv.Deltas[10]
+	if !ok {
+		t.Fatalf("expected delta for PID 10")
+	}
+	if d.Tokens != 2500 {
+		t.Fatalf("expected 2500 token delta, got %d", d.Tokens)
+	}
+	if d.BurnRate < 29.99 || d.BurnRate > 30.01 {
+		t.Fatalf("expected $30/h burn rate, got %f", d.BurnRate)
+	}
+	if stats.tokens != 2500 {
+		t.Fatalf("expected session total of 2500 tokens, got %d", stats.tokens)
+	}
+}
+
+func TestWatchStatsHandlesCounterReset(t *testing.T) {
+	start := time.Now()
+	stats := newWatchStats(start)
+
+	stats.observe(watchState(start, 5000, 0.5))
+	iv := stats.observe(watchState(start.Add(time.Minute), 200, 0.01))
+	if d := iv.Deltas[10]; d.Tokens != 200 {
+		t.Fatalf("expected reset delta of 200, got %d", d.Tokens)
+	}
+}
+
+func TestWatchStatsReportsOnlyNewEvents(t *testing.T) {
+	start := time.Now()
+	stats := newWatchStats(start)
+
+	st := watchState(start, 0, 0)
+	st.Alerts = []agent.Alert{{Timestamp: start, Level: agent.AlertWarning}}
+
+	if iv := stats.observe(st); len(iv.Alerts) != 1 {
+		t.Fatalf("expected 1 new alert, got %d", len(iv.Alerts))
+	}
+	if iv := stats.observe(st); len(iv.Alerts) != 0 {
+		t.Fatalf("expected repeated alert to be skipped, got %d", len(iv.Alerts))
+	}
+
+	var buf bytes.Buffer
+	stats.writeSummary(&buf, start.Add(time.Hour))
+	if !strings.Contains(buf.String(), "Alerts:          1") {
+		t.Fatalf("expected summary to count 1 alert, got:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,41 @@
+package collector
+
+import (
+	"sync"
+	"time"
+)
+
+// Cache serves a source's state, refreshing it at most once per ttl.
+// It is safe for concurrent use, so HTTP handlers can share one.
+type Cache struct {
+	source Source
+	ttl    time.Duration
+
+	mu      sync.Mutex
+	state   State
+	err     error
+	fetched time.Time
+}
+
+// NewCache wraps source with a ttl-based cache
+func NewCache(source Source, ttl time.Duration) *Cache {
+	return &Cache{source: source, ttl: ttl}
+}
+
+// State returns the cached state, refreshing it when stale
+func (c *Cache) State() (State, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.fetched.IsZero() && time.Since(c.fetched) < c.ttl {
+		return c.state, c.err
+	}
+
+	st, err := c.source.State()
+	c.fetched = time.Now()
+	c.err = err
+	if err == nil {
+		c.state = st
+	}
+	return c.state, c.err
+}
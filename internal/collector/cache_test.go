@@ -0,0 +1,62 @@
+package collector
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+)
+
+type countingSource struct {
+	calls int
+	err   error
+}
+
+func (s *countingSource) State() (State, error) {
+	s.calls++
+	return State{Agents: make([]agent.Instance, s.calls)}, s.err
+}
+
+func TestCacheReusesStateWithinTTL(t *testing.T) {
+	src := &countingSource{}
+	cache := NewCache(src, time.Hour)
+
+	for i := 0; i < 3; i++ {
+		if _, err := cache.State(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if src.calls != 1 {
+		t.Fatalf("expected 1 source call within ttl, got %d", src.calls)
+	}
+}
+
+func TestCacheRefreshesWhenStale(t *testing.T) {
+	src := &countingSource{}
+	cache := NewCache(src, 0)
+
+	cache.State()
+	st, _ := cache.State()
+	if src.calls != 2 {
+		t.Fatalf("expected 2 source calls with zero ttl, got %d", src.calls)
+	}
+	if len(st.Agents) != 2 {
+		t.Fatalf("expected state from second call, got %d agents", len(st.Agents))
+	}
+}
+
+func TestCacheKeepsLastGoodStateOnError(t *testing.T) {
+	src := &countingSource{}
+	cache := NewCache(src, 0)
+
+	cache.State()
+	src.err = errors.New("scan failed")
+	st, err := cache.State()
+	if err == nil {
+		t.Fatalf("expected error to be reported")
+	}
+	if len(st.Agents) != 1 {
+		t.Fatalf("expected last good state to be kept, got %d agents", len(st.Agents))
+	}
+}
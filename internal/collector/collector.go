@@ -0,0 +1,440 @@
+package collector
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+	"github.com/Rafiki81/libagentmetrics/config"
+	"github.com/Rafiki81/libagentmetrics/monitor"
+	"github.com/rafaelperezbeato/agentmetrics/internal/appconfig"
+	"github.com/rafaelperezbeato/agentmetrics/internal/budget"
+	"github.com/rafaelperezbeato/agentmetrics/internal/enforce"
+	"github.com/rafaelperezbeato/agentmetrics/internal/hooks"
+	"github.com/rafaelperezbeato/agentmetrics/internal/lifecycle"
+	"github.com/rafaelperezbeato/agentmetrics/internal/review"
+	"github.com/rafaelperezbeato/agentmetrics/internal/rules"
+	"github.com/rafaelperezbeato/agentmetrics/internal/sessions"
+	"github.com/rafaelperezbeato/agentmetrics/internal/suppress"
+)
+
+// State is the fully enriched result of one collection cycle
+type State struct {
+	Timestamp      time.Time              `json:"timestamp"`
+	Agents         []agent.Instance       `json:"agents"`
+	Alerts         []agent.Alert          `json:"alerts"`
+	SecurityEvents []agent.SecurityEvent  `json:"security_events"`
+	LocalModels    []agent.LocalModelInfo `json:"local_models"`
+	Budgets        []budget.Usage         `json:"budgets,omitempty"`
+	Enforcement    []enforce.Action       `json:"enforcement,omitempty"`
+	Suppressions   []suppress.Count       `json:"suppressions,omitempty"`
+	Hooks          []hooks.Result         `json:"hooks,omitempty"`
+	Lifecycle      []lifecycle.Event      `json:"lifecycle,omitempty"`
+	// SinkErrors holds each failing sink's latest error, "name: error"
+	SinkErrors []string `json:"sink_errors,omitempty"`
+	// DecisionsError is why the review decisions file couldn't be loaded
+	DecisionsError string `json:"decisions_error,omitempty"`
+	// Reviewed holds the review keys of security events already settled
+	// in the review queue
+	Reviewed []string `json:"reviewed,omitempty"`
+}
+
+// Source supplies already-enriched state, e.g. a running daemon
+type Source interface {
+	State() (State, error)
+}
+
+// Resolver decides what happens to a process paused by enforcement
+type Resolver interface {
+	Resume(pid int) error
+	Terminate(pid int) error
+}
+
+// Mode says whether a collector acts on what it finds
+type Mode int
+
+const (
+	// ReportOnly collects and reports without signalling any process.
+	// One-shot commands and watch use it, so a status query never stops
+	// an agent.
+	ReportOnly Mode = iota
+	// Act also enforces budget caps and the security enforcement mode
+	// and runs hooks. Only the daemon, serve and the TUI, which own a
+	// long-running pipeline, act.
+	Act
+)
+
+// Collector owns the long-lived monitors behind the enrichment pipeline.
+// Monitors keep history between scans (token deltas, alert cooldowns,
+// security dedup), so a single Collector should be reused across cycles.
+type Collector struct {
+	config        *config.Config
+	mode          Mode
+	detector      *agent.Detector
+	fileMon       *monitor.FileWatcher
+	netMon        *monitor.NetworkMonitor
+	tokenMon      *monitor.TokenMonitor
+	gitMon        *monitor.GitMonitor
+	termMon       *monitor.TerminalMonitor
+	sessionMon    *monitor.SessionMonitor
+	alertMon      *monitor.AlertMonitor
+	secMon        *monitor.SecurityMonitor
+	localModelMon *monitor.LocalModelMonitor
+	history       *monitor.HistoryStore
+	sessions      *sessions.Tracker
+	reports       *sessions.Store
+	budgets       *budget.Tracker
+	enforcer      *enforce.Enforcer
+	suppress      *suppress.Filter
+	decisions     *review.Store
+	customRules   *rules.Engine
+	hooks         *hooks.Runner
+	lifecycle     *lifecycle.Tracker
+	sinks         []Sink
+
+	// Hook triggers: new alerts/events and caps already reported as
+	// exceeded
+	hookMark     Watermark
+	budgetsFired map[string]bool
+}
+
+// New creates a collector with monitors built from the library config and
+// the app-only sections
+func New(cfg *config.Config, appCfg *appconfig.Config, mode Mode) *Collector {
+	registry := agent.NewRegistry()
+
+	// Build alert thresholds from config
+	thresholds := monitor.AlertThresholds{
+		CPUWarning:      cfg.Alerts.CPUWarning,
+		CPUCritical:     cfg.Alerts.CPUCritical,
+		MemoryWarning:   cfg.Alerts.MemoryWarning,
+		MemoryCritical:  cfg.Alerts.MemoryCritical,
+		TokenWarning:    cfg.Alerts.TokenWarning,
+		TokenCritical:   cfg.Alerts.TokenCritical,
+		CostWarning:     cfg.Alerts.CostWarning,
+		CostCritical:    cfg.Alerts.CostCritical,
+		IdleMinutes:     cfg.Alerts.IdleMinutes,
+		CooldownMinutes: cfg.Alerts.CooldownMinutes,
+		MaxAlerts:       cfg.Alerts.MaxAlerts,
+	}
+
+	history := monitor.NewHistoryStore(cfg.Export.Directory, cfg.Export.MaxHistory)
+	reports := sessions.NewStore(sessions.DirFor(history.DataDir()))
+
+	var budgets *budget.Tracker
+	if appCfg.Budgets.Enabled {
+		budgets = budget.NewTracker(appCfg.Budgets, reports)
+	}
+
+	var enforcer *enforce.Enforcer
+	if m := appCfg.Security.Enforcement.Mode; mode == Act && m != "" && m != enforce.ModeOff {
+		enforcer = enforce.New(appCfg.Security.Enforcement, filepath.Join(history.DataDir(), "enforcement.jsonl"))
+	}
+
+	var filter *suppress.Filter
+	if len(appCfg.Security.Suppressions) > 0 {
+		filter = suppress.New(appCfg.Security.Suppressions)
+	}
+
+	// A broken decisions file is reported on every state and retried
+	decisions, _ := review.Open(review.DefaultPath())
+
+	var customRules *rules.Engine
+	if len(appCfg.Security.CustomRules) > 0 {
+		customRules = rules.New(appCfg.Security.CustomRules)
+	}
+
+	// Hooks are actions too: a one-shot query must not run them
+	var runner *hooks.Runner
+	if mode == Act && len(appCfg.Hooks) > 0 {
+		runner = hooks.New(appCfg.Hooks, filepath.Join(history.DataDir(), "hooks.jsonl"))
+	}
+
+	return &Collector{
+		config:        cfg,
+		mode:          mode,
+		detector:      agent.NewDetector(registry, cfg),
+		fileMon:       monitor.NewFileWatcher(cfg.Monitor.MaxFileOps),
+		netMon:        monitor.NewNetworkMonitor(),
+		tokenMon:      monitor.NewTokenMonitor(),
+		gitMon:        monitor.NewGitMonitor(),
+		termMon:       monitor.NewTerminalMonitor(cfg.Monitor.MaxTermCommands),
+		sessionMon:    monitor.NewSessionMonitor(),
+		alertMon:      monitor.NewAlertMonitor(thresholds),
+		secMon:        monitor.NewSecurityMonitor(cfg.Security),
+		localModelMon: monitor.NewLocalModelMonitor(cfg.LocalModels),
+		history:       history,
+		sessions:      sessions.NewTracker(),
+		reports:       reports,
+		budgets:       budgets,
+		enforcer:      enforcer,
+		suppress:      filter,
+		decisions:     decisions,
+		customRules:   customRules,
+		hooks:         runner,
+		lifecycle:     lifecycle.NewTracker(),
+		budgetsFired:  map[string]bool{},
+	}
+}
+
+// AddSink registers a sink to receive every enriched state
+func (c *Collector) AddSink(s Sink) {
+	c.sinks = append(c.sinks, s)
+}
+
+// Start starts the background file watcher
+func (c *Collector) Start() {
+	c.fileMon.Start(1 * time.Second)
+}
+
+// Stop stops the background file watcher, waits for queued hooks and
+// saves interrupted reports for the agents still running
+func (c *Collector) Stop() {
+	c.fileMon.Stop()
+	if c.hooks != nil {
+		c.hooks.Close()
+	}
+	for _, r := range c.sessions.Flush(time.Now()) {
+		_ = c.reports.Save(&r)
+	}
+}
+
+// History returns the history store the collector records into
+func (c *Collector) History() *monitor.HistoryStore {
+	return c.history
+}
+
+// Scan runs process detection only. It is safe to call from a goroutine
+// while Enrich is not running.
+func (c *Collector) Scan() ([]agent.Instance, error) {
+	return c.detector.Scan()
+}
+
+// Collect scans and enriches in one step
+func (c *Collector) Collect() (State, error) {
+	agents, err := c.Scan()
+	if err != nil {
+		return State{}, err
+	}
+	return c.Enrich(agents), nil
+}
+
+// Enrich runs every monitor over freshly scanned agents
+func (c *Collector) Enrich(agents []agent.Instance) State {
+	st := State{
+		Timestamp: time.Now(),
+		Agents:    agents,
+	}
+
+	// Update file watcher with agent working dirs
+	for _, a := range agents {
+		if a.WorkDir != "" {
+			c.fileMon.AddDir(a.WorkDir)
+		}
+	}
+
+	// Update network info
+	for i, a := range agents {
+		agents[i].NetConns = c.netMon.GetConnections(a.PID)
+	}
+
+	// Update file ops
+	for i, a := range agents {
+		if a.WorkDir != "" {
+			agents[i].FileOps = c.fileMon.GetOperationsForDir(a.WorkDir)
+		}
+	}
+
+	// Collect token metrics (includes cost + latency)
+	c.tokenMon.Collect(agents)
+
+	// Collect git activity + LOC
+	for i := range agents {
+		c.gitMon.Collect(&agents[i])
+	}
+
+	// Collect terminal commands
+	for i := range agents {
+		c.termMon.Collect(&agents[i])
+	}
+
+	// Update session metrics
+	for i := range agents {
+		c.sessionMon.Collect(&agents[i])
+	}
+
+	// Diff against the previous scan once status, model and branch are known
+	transitions := c.lifecycle.Observe(agents, st.Timestamp)
+	st.Lifecycle = c.lifecycle.Events()
+
+	// Check alerts
+	if c.config.Alerts.Enabled {
+		for i := range agents {
+			c.alertMon.Check(&agents[i])
+		}
+		st.Alerts = c.alertMon.GetRecentAlerts(30)
+	}
+
+	// Security analysis (after terminal + file + network data is collected)
+	if c.config.Security.Enabled {
+		for i := range agents {
+			c.secMon.CheckAgent(&agents[i])
+		}
+		st.SecurityEvents = c.secMon.GetRecentEvents(60)
+
+		// Custom rules run alongside the built-in categories
+		if c.customRules != nil {
+			c.customRules.Check(agents, st.Timestamp)
+			st.SecurityEvents = rules.Merge(st.SecurityEvents, c.customRules.Events())
+		}
+	}
+
+	// Drop allow-listed events before anything downstream sees them
+	if c.suppress != nil {
+		st.SecurityEvents = c.suppress.Apply(st.SecurityEvents, agents, st.Timestamp)
+		st.Suppressions = c.suppress.Counts(st.Timestamp)
+	}
+
+	// Reviewed events stay in the state: a decision only keeps them from
+	// notifying and running hooks again, while enforcement and the audit
+	// log see every event. Decisions may come from another process.
+	if err := c.decisions.Reload(); err != nil {
+		st.DecisionsError = err.Error()
+	}
+	for _, evt := range st.SecurityEvents {
+		if _, decided := c.decisions.Status(evt); decided {
+			st.Reviewed = append(st.Reviewed, review.Key(evt))
+		}
+	}
+
+	// Stop the processes behind blocked CRITICAL events
+	if c.enforcer != nil {
+		c.enforcer.Check(agents, st.SecurityEvents, st.Timestamp)
+		st.Enforcement = c.enforcer.Actions()
+	}
+
+	// Record history
+	c.history.Record(agents)
+
+	// Write a final report for every agent that exited since the last scan.
+	// A failed write must not stop collection, so errors are dropped, but
+	// a session another collector already saved is only counted once.
+	for _, r := range c.sessions.Observe(agents, st.SecurityEvents, st.Timestamp) {
+		err := c.reports.Save(&r)
+		if c.budgets != nil && !errors.Is(err, os.ErrExist) {
+			c.budgets.Record(r)
+		}
+	}
+
+	// Budgets span sessions: finished reports plus live cost
+	if c.budgets != nil {
+		st.Budgets = c.budgets.Check(agents, st.Timestamp)
+		if c.mode == Act {
+			c.budgets.Enforce(st.Budgets, agents, st.Timestamp)
+		}
+	}
+
+	st.Alerts = c.mergeAlerts(st.Alerts)
+
+	// Run user hooks for everything that happened this cycle
+	if c.hooks != nil {
+		c.hooks.Dispatch(c.hookEvents(st, transitions), agents)
+		st.Hooks = c.hooks.Results()
+	}
+
+	// Collect local model server info
+	if c.config.LocalModels.Enabled {
+		st.LocalModels = c.localModelMon.Collect()
+	}
+
+	// Sinks deliver in the background, so these are earlier cycles' errors
+	st.SinkErrors = c.sinkErrors()
+	for _, sink := range c.sinks {
+		sink.Publish(st)
+	}
+
+	return st
+}
+
+// sinkErrors returns the latest error of every sink that reports one
+func (c *Collector) sinkErrors() []string {
+	var errs []string
+	for _, sink := range c.sinks {
+		if r, ok := sink.(Reporter); ok {
+			if err := r.LastError(); err != nil {
+				errs = append(errs, r.Name()+": "+err.Error())
+			}
+		}
+	}
+	return errs
+}
+
+// mergeAlerts adds budget and enforcement alerts to the monitor's, in
+// time order
+func (c *Collector) mergeAlerts(alerts []agent.Alert) []agent.Alert {
+	if c.budgets == nil && c.enforcer == nil {
+		return alerts
+	}
+	if c.budgets != nil {
+		alerts = slices.Concat(alerts, c.budgets.Alerts())
+	}
+	if c.enforcer != nil {
+		alerts = slices.Concat(alerts, c.enforcer.Alerts())
+	}
+	sort.SliceStable(alerts, func(i, j int) bool {
+		return alerts[i].Timestamp.Before(alerts[j].Timestamp)
+	})
+	return alerts
+}
+
+// hookEvents returns the alerts, security events, lifecycle transitions
+// and exceeded budgets that are new since the previous cycle
+func (c *Collector) hookEvents(st State, transitions []lifecycle.Event) []hooks.Event {
+	var events []hooks.Event
+
+	alerts, secEvents := c.hookMark.NewEvents(st)
+	for _, al := range alerts {
+		events = append(events, hooks.FromAlert(al))
+	}
+	for _, evt := range Unreviewed(st, secEvents) {
+		events = append(events, hooks.FromSecurity(evt))
+	}
+
+	for _, e := range transitions {
+		events = append(events, hooks.FromLifecycle(e))
+	}
+
+	for _, u := range st.Budgets {
+		key := u.Budget + "|" + u.Period + "|" + u.Since.Format(time.DateOnly)
+		if u.Exceeded() && !c.budgetsFired[key] {
+			c.budgetsFired[key] = true
+			events = append(events, hooks.FromBudget(u, st.Timestamp))
+		}
+	}
+	return events
+}
+
+// Resume continues a process paused by enforcement
+func (c *Collector) Resume(pid int) error {
+	if c.enforcer == nil {
+		return errors.New("enforcement is off")
+	}
+	return c.enforcer.Resume(pid)
+}
+
+// Terminate kills a process paused by enforcement
+func (c *Collector) Terminate(pid int) error {
+	if c.enforcer == nil {
+		return errors.New("enforcement is off")
+	}
+	return c.enforcer.Terminate(pid)
+}
+
+// State collects a fresh snapshot, making a Collector usable as a Source
+func (c *Collector) State() (State, error) {
+	return c.Collect()
+}
@@ -0,0 +1,123 @@
+package collector
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+	"github.com/Rafiki81/libagentmetrics/config"
+	"github.com/rafaelperezbeato/agentmetrics/internal/appconfig"
+	"github.com/rafaelperezbeato/agentmetrics/internal/review"
+	"github.com/rafaelperezbeato/agentmetrics/internal/rules"
+)
+
+type plainSink struct{}
+
+func (plainSink) Publish(State) {}
+
+type failingSink struct {
+	name string
+	err  error
+}
+
+func (s failingSink) Publish(State)    {}
+func (s failingSink) Name() string     { return s.name }
+func (s failingSink) LastError() error { return s.err }
+
+func TestSinkErrorsNamesFailingSinks(t *testing.T) {
+	c := &Collector{}
+	c.AddSink(plainSink{})
+	c.AddSink(failingSink{name: "otlp"})
+	c.AddSink(failingSink{name: "webhooks", err: errors.New("server returned 500")})
+
+	want := []string{"webhooks: server returned 500"}
+	if got := c.sinkErrors(); !slices.Equal(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestOnlyActingCollectorsRunHooks(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	appCfg := appconfig.Default()
+	appCfg.Hooks = []appconfig.Hook{{Name: "log", On: "alert", Command: "true"}}
+
+	if c := New(config.DefaultConfig(), appCfg, ReportOnly); c.hooks != nil {
+		t.Fatal("a report-only collector must not run hooks")
+	}
+
+	c := New(config.DefaultConfig(), appCfg, Act)
+	if c.hooks == nil {
+		t.Fatal("an acting collector runs hooks")
+	}
+	c.Start()
+	c.Stop()
+}
+
+func TestUnreviewedKeepsOnlyPendingEvents(t *testing.T) {
+	settled := agent.SecurityEvent{AgentID: "claude-code", Category: "destructive", Detail: "rm -rf /"}
+	pending := agent.SecurityEvent{AgentID: "claude-code", Category: "destructive", Detail: "rm -rf build"}
+	st := State{SecurityEvents: []agent.SecurityEvent{settled, pending}, Reviewed: []string{review.Key(settled)}}
+
+	got := Unreviewed(st, st.SecurityEvents)
+	if len(got) != 1 || got[0].Detail != "rm -rf build" {
+		t.Fatalf("expected only the pending event, got %+v", got)
+	}
+	if len(st.SecurityEvents) != 2 {
+		t.Fatal("the state keeps reviewed events for the audit log and enforcement")
+	}
+}
+
+func TestCustomFileRulesSeeWatchedOperations(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	workDir := t.TempDir()
+	appCfg := appconfig.Default()
+	appCfg.Security.CustomRules = []appconfig.SecurityRule{
+		{Name: "ci config", Target: "file", Glob: "{workdir}/.github/workflows/**", Severity: "high"},
+		{Name: "outside", Target: "file", Glob: "{workdir}/**", Negate: true, Severity: "high"},
+	}
+	cfg := config.DefaultConfig()
+	cfg.Security.Enabled = true
+
+	c := New(cfg, appCfg, ReportOnly)
+	c.Start()
+	defer c.Stop()
+
+	a := agent.Instance{PID: os.Getpid(), WorkDir: workDir, Info: agent.Info{ID: "claude-code"}}
+	c.Enrich([]agent.Instance{a})
+	if err := os.MkdirAll(filepath.Join(workDir, ".github", "workflows"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(workDir, ".github", "workflows", "ci.yml"), []byte("on: push\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The watcher polls in the background
+	var st State
+	for deadline := time.Now().Add(3 * time.Second); time.Now().Before(deadline); time.Sleep(200 * time.Millisecond) {
+		st = c.Enrich([]agent.Instance{a})
+		if len(st.Agents[0].FileOps) > 0 {
+			break
+		}
+	}
+	if len(st.Agents[0].FileOps) == 0 {
+		t.Skip("the file watcher reported no operations on this platform")
+	}
+
+	var fired []string
+	for _, evt := range st.SecurityEvents {
+		if evt.Category == rules.Category {
+			fired = append(fired, evt.Description)
+		}
+	}
+	if !slices.Contains(fired, "ci config") {
+		t.Fatalf("expected the workflow write to fire, got %v", fired)
+	}
+	// Only the working directory is watched
+	if slices.Contains(fired, "outside") {
+		t.Fatalf("a negated {workdir}/** rule can't match a watched path, got %v", fired)
+	}
+}
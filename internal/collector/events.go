@@ -0,0 +1,77 @@
+package collector
+
+import (
+	"slices"
+	"time"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+	"github.com/rafaelperezbeato/agentmetrics/internal/review"
+)
+
+// Sink receives every state the collector produces. Publish is called on
+// the collection path, so implementations must return quickly and push
+// slow work (network, subprocesses) to the background.
+type Sink interface {
+	Publish(State)
+}
+
+// Reporter is a sink that fails in the background and keeps its most
+// recent error so the user can be told about it
+type Reporter interface {
+	Sink
+	Name() string
+	LastError() error
+}
+
+// Unreviewed drops the events already settled in the review queue.
+// Notifiers use it so an acknowledged event doesn't alert again; records
+// such as the audit log and exporters keep every event.
+func Unreviewed(st State, events []agent.SecurityEvent) []agent.SecurityEvent {
+	if len(st.Reviewed) == 0 {
+		return events
+	}
+	var out []agent.SecurityEvent
+	for _, evt := range events {
+		if !slices.Contains(st.Reviewed, review.Key(evt)) {
+			out = append(out, evt)
+		}
+	}
+	return out
+}
+
+// Watermark picks out alerts and security events that appeared since the
+// previous call. Monitors only report recent events, so consumers that act
+// once per event (counters, exporters, notifiers) use it to skip repeats.
+type Watermark struct {
+	lastAlert    time.Time
+	lastSecurity time.Time
+}
+
+// NewEvents returns the alerts and security events newer than last time
+func (w *Watermark) NewEvents(st State) ([]agent.Alert, []agent.SecurityEvent) {
+	var alerts []agent.Alert
+	newest := w.lastAlert
+	for _, al := range st.Alerts {
+		if al.Timestamp.After(w.lastAlert) {
+			alerts = append(alerts, al)
+			if al.Timestamp.After(newest) {
+				newest = al.Timestamp
+			}
+		}
+	}
+	w.lastAlert = newest
+
+	var events []agent.SecurityEvent
+	newest = w.lastSecurity
+	for _, evt := range st.SecurityEvents {
+		if evt.Timestamp.After(w.lastSecurity) {
+			events = append(events, evt)
+			if evt.Timestamp.After(newest) {
+				newest = evt.Timestamp
+			}
+		}
+	}
+	w.lastSecurity = newest
+
+	return alerts, events
+}
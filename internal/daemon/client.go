@@ -0,0 +1,87 @@
+package daemon
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/rafaelperezbeato/agentmetrics/internal/collector"
+)
+
+// Client talks to a running daemon over its Unix socket
+type Client struct {
+	path    string
+	timeout time.Duration
+}
+
+// Dial returns a client for the daemon at path, failing fast when
+// nothing is listening there.
+func Dial(path string) (*Client, error) {
+	c := &Client{path: path, timeout: 2 * time.Second}
+	if err := c.Ping(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Ping checks that the daemon is alive
+func (c *Client) Ping() error {
+	_, err := c.call(cmdPing)
+	return err
+}
+
+// State fetches the latest enriched snapshot from the daemon
+func (c *Client) State() (collector.State, error) {
+	resp, err := c.call(cmdState)
+	if err != nil {
+		return collector.State{}, err
+	}
+	if resp.State == nil {
+		return collector.State{}, errors.New("daemon returned no state")
+	}
+	return *resp.State, nil
+}
+
+// Stop asks the daemon to shut down
+func (c *Client) Stop() error {
+	_, err := c.call(cmdStop)
+	return err
+}
+
+// Resume continues a process the daemon paused
+func (c *Client) Resume(pid int) error {
+	_, err := c.call(fmt.Sprintf("%s %d", cmdResume, pid))
+	return err
+}
+
+// Terminate kills a process the daemon paused
+func (c *Client) Terminate(pid int) error {
+	_, err := c.call(fmt.Sprintf("%s %d", cmdTerminate, pid))
+	return err
+}
+
+// call sends one command and decodes the reply
+func (c *Client) call(cmd string) (response, error) {
+	var resp response
+
+	conn, err := net.DialTimeout("unix", c.path, c.timeout)
+	if err != nil {
+		return resp, fmt.Errorf("connecting to daemon: %w", err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(c.timeout))
+
+	if _, err := fmt.Fprintf(conn, "%s\n", cmd); err != nil {
+		return resp, fmt.Errorf("sending %s: %w", cmd, err)
+	}
+	if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&resp); err != nil {
+		return resp, fmt.Errorf("reading %s reply: %w", cmd, err)
+	}
+	if !resp.OK {
+		return resp, fmt.Errorf("daemon: %s", resp.Error)
+	}
+	return resp, nil
+}
@@ -0,0 +1,224 @@
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Rafiki81/libagentmetrics/config"
+	"github.com/rafaelperezbeato/agentmetrics/internal/appconfig"
+	"github.com/rafaelperezbeato/agentmetrics/internal/collector"
+)
+
+// Protocol commands understood by the daemon socket
+const (
+	cmdPing  = "ping"
+	cmdState = "state"
+	cmdStop  = "stop"
+	// cmdResume and cmdTerminate take a PID paused by enforcement
+	cmdResume    = "resume"
+	cmdTerminate = "terminate"
+)
+
+// response is the single JSON line written back for every request
+type response struct {
+	OK    bool             `json:"ok"`
+	Error string           `json:"error,omitempty"`
+	State *collector.State `json:"state,omitempty"`
+}
+
+// SocketPath returns the default socket location next to the config file
+func SocketPath() string {
+	return filepath.Join(filepath.Dir(config.ConfigPath()), "agentmetrics.sock")
+}
+
+// Server runs the collection loop and serves the latest state over a Unix socket
+type Server struct {
+	config    *config.Config
+	collector *collector.Collector
+	resolver  collector.Resolver
+	path      string
+
+	mu      sync.RWMutex
+	state   collector.State
+	lastErr error
+	ready   bool
+
+	stop chan struct{}
+	once sync.Once
+}
+
+// NewServer creates a daemon server listening on path
+func NewServer(cfg *config.Config, appCfg *appconfig.Config, path string) *Server {
+	c := collector.New(cfg, appCfg, collector.Act)
+	return &Server{
+		config:    cfg,
+		collector: c,
+		resolver:  c,
+		path:      path,
+		stop:      make(chan struct{}),
+	}
+}
+
+// AddSink registers a sink on the daemon's collector
+func (s *Server) AddSink(sink collector.Sink) {
+	s.collector.AddSink(sink)
+}
+
+// Run collects on every refresh interval until ctx is cancelled or a
+// client sends the stop command.
+func (s *Server) Run(ctx context.Context) error {
+	if err := s.removeStaleSocket(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("creating socket dir: %w", err)
+	}
+
+	ln, err := net.Listen("unix", s.path)
+	if err != nil {
+		return fmt.Errorf("listening on %s: %w", s.path, err)
+	}
+	defer os.Remove(s.path)
+	defer ln.Close()
+
+	if err := os.Chmod(s.path, 0o600); err != nil {
+		return fmt.Errorf("restricting socket permissions: %w", err)
+	}
+
+	s.collector.Start()
+	defer s.collector.Stop()
+
+	go s.acceptLoop(ln)
+
+	interval := s.config.RefreshInterval.Duration()
+	if interval <= 0 {
+		interval = 3 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	s.collect()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.stop:
+			return nil
+		case <-ticker.C:
+			s.collect()
+		}
+	}
+}
+
+// collect runs one enrichment cycle and publishes the result
+func (s *Server) collect() {
+	st, err := s.collector.Collect()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastErr = err
+	if err == nil {
+		s.state = st
+		s.ready = true
+	}
+}
+
+// acceptLoop serves clients until the listener is closed
+func (s *Server) acceptLoop(ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+// handle answers a single request line
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil && line == "" {
+		return
+	}
+
+	var resp response
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		fields = []string{""}
+	}
+	switch fields[0] {
+	case cmdPing:
+		resp.OK = true
+	case cmdState:
+		s.mu.RLock()
+		switch {
+		case s.ready:
+			st := s.state
+			resp.OK = true
+			resp.State = &st
+		case s.lastErr != nil:
+			resp.Error = s.lastErr.Error()
+		default:
+			resp.Error = "daemon is still collecting its first snapshot"
+		}
+		s.mu.RUnlock()
+	case cmdStop:
+		resp.OK = true
+		s.once.Do(func() { close(s.stop) })
+	case cmdResume, cmdTerminate:
+		if err := s.resolve(fields); err != nil {
+			resp.Error = err.Error()
+		} else {
+			resp.OK = true
+		}
+	default:
+		resp.Error = fmt.Sprintf("unknown command: %q", strings.TrimSpace(line))
+	}
+
+	_ = json.NewEncoder(conn).Encode(resp)
+}
+
+// resolve resumes or terminates a paused process on behalf of a client
+func (s *Server) resolve(fields []string) error {
+	if len(fields) != 2 {
+		return fmt.Errorf("usage: %s <pid>", fields[0])
+	}
+	pid, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return fmt.Errorf("invalid pid: %q", fields[1])
+	}
+	if s.resolver == nil {
+		return errors.New("enforcement is off")
+	}
+	if fields[0] == cmdResume {
+		return s.resolver.Resume(pid)
+	}
+	return s.resolver.Terminate(pid)
+}
+
+// removeStaleSocket refuses to start twice and cleans up after crashes
+func (s *Server) removeStaleSocket() error {
+	if _, err := os.Stat(s.path); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if client, err := Dial(s.path); err == nil && client.Ping() == nil {
+		return fmt.Errorf("daemon already running on %s", s.path)
+	}
+	if err := os.Remove(s.path); err != nil {
+		return fmt.Errorf("removing stale socket: %w", err)
+	}
+	return nil
+}
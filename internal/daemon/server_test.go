@@ -0,0 +1,125 @@
+package daemon
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+	"github.com/rafaelperezbeato/agentmetrics/internal/collector"
+)
+
+func startTestServer(t *testing.T, s *Server) string {
+	t.Helper()
+
+	s.path = filepath.Join(t.TempDir(), "test.sock")
+	s.stop = make(chan struct{})
+
+	ln, err := net.Listen("unix", s.path)
+	if err != nil {
+		t.Fatalf("listening: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go s.acceptLoop(ln)
+	return s.path
+}
+
+func TestClientFetchesServerState(t *testing.T) {
+	s := &Server{
+		ready: true,
+		state: collector.State{
+			Timestamp: time.Now(),
+			Agents:    []agent.Instance{{PID: 42}, {PID: 43}},
+		},
+	}
+	path := startTestServer(t, s)
+
+	client, err := Dial(path)
+	if err != nil {
+		t.Fatalf("dialing daemon: %v", err)
+	}
+
+	st, err := client.State()
+	if err != nil {
+		t.Fatalf("fetching state: %v", err)
+	}
+	if len(st.Agents) != 2 || st.Agents[0].PID != 42 {
+		t.Fatalf("expected 2 agents starting with PID 42, got %+v", st.Agents)
+	}
+}
+
+func TestClientStateBeforeFirstCollection(t *testing.T) {
+	path := startTestServer(t, &Server{})
+
+	client, err := Dial(path)
+	if err != nil {
+		t.Fatalf("dialing daemon: %v", err)
+	}
+	if _, err := client.State(); err == nil {
+		t.Fatalf("expected error before first collection")
+	}
+}
+
+func TestClientStopClosesStopChannel(t *testing.T) {
+	s := &Server{}
+	path := startTestServer(t, s)
+
+	client, err := Dial(path)
+	if err != nil {
+		t.Fatalf("dialing daemon: %v", err)
+	}
+	if err := client.Stop(); err != nil {
+		t.Fatalf("stopping daemon: %v", err)
+	}
+
+	select {
+	case <-s.stop:
+	case <-time.After(time.Second):
+		t.Fatalf("expected stop channel to be closed")
+	}
+}
+
+type fakeResolver struct {
+	resumed, terminated []int
+}
+
+func (f *fakeResolver) Resume(pid int) error {
+	f.resumed = append(f.resumed, pid)
+	return nil
+}
+
+func (f *fakeResolver) Terminate(pid int) error {
+	f.terminated = append(f.terminated, pid)
+	return nil
+}
+
+func TestClientResolvesPausedProcess(t *testing.T) {
+	resolver := &fakeResolver{}
+	path := startTestServer(t, &Server{resolver: resolver})
+
+	client, err := Dial(path)
+	if err != nil {
+		t.Fatalf("dialing daemon: %v", err)
+	}
+	if err := client.Resume(300); err != nil {
+		t.Fatalf("resuming: %v", err)
+	}
+	if err := client.Terminate(301); err != nil {
+		t.Fatalf("terminating: %v", err)
+	}
+	if len(resolver.resumed) != 1 || resolver.resumed[0] != 300 || len(resolver.terminated) != 1 || resolver.terminated[0] != 301 {
+		t.Fatalf("unexpected calls: %+v", resolver)
+	}
+
+	if _, err := client.call("resume abc"); err == nil {
+		t.Fatalf("expected error for invalid pid")
+	}
+}
+
+func TestDialFailsWithoutDaemon(t *testing.T) {
+	if _, err := Dial(filepath.Join(t.TempDir(), "missing.sock")); err == nil {
+		t.Fatalf("expected dial error when no daemon is listening")
+	}
+}
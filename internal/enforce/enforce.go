@@ -0,0 +1,385 @@
+package enforce
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+	"github.com/rafaelperezbeato/agentmetrics/internal/appconfig"
+)
+
+// Enforcement modes
+const (
+	ModeOff   = "off"
+	ModePause = "pause"
+	ModeKill  = "kill"
+)
+
+// Action statuses
+const (
+	StatusPaused     = "paused"
+	StatusKilled     = "killed"
+	StatusResumed    = "resumed"
+	StatusTerminated = "terminated"
+	StatusDryRun     = "dry-run"
+	StatusNotRunning = "not-running"
+	StatusFailed     = "failed"
+	// StatusAmbiguous means several descendants run the command, so none
+	// is signalled
+	StatusAmbiguous = "ambiguous"
+)
+
+// maxActions caps the actions kept in memory
+const maxActions = 50
+
+// Action records what was done to one offending process
+type Action struct {
+	Timestamp time.Time `json:"timestamp"`
+	AgentID   string    `json:"agent_id"`
+	AgentName string    `json:"agent_name"`
+	AgentPID  int       `json:"agent_pid"`
+	PID       int       `json:"pid,omitempty"`
+	Command   string    `json:"command"`
+	Reason    string    `json:"reason"`
+	Mode      string    `json:"mode"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// process is one row of the process table
+type process struct {
+	pid  int
+	ppid int
+	args string
+}
+
+// Enforcer stops the child processes behind blocked CRITICAL security
+// events. Paused processes wait for Resume or Terminate. It is safe for
+// concurrent use, so a daemon can resolve actions from client requests
+// while collecting.
+type Enforcer struct {
+	mode    string
+	dryRun  bool
+	logPath string
+
+	mu      sync.Mutex
+	seen    map[string]bool
+	actions []Action
+	alerts  []agent.Alert
+
+	signal    func(pid int, sig syscall.Signal) error
+	processes func() ([]process, error)
+}
+
+// New creates an enforcer that appends every action to logPath as JSON lines
+func New(cfg appconfig.EnforcementConfig, logPath string) *Enforcer {
+	return &Enforcer{
+		mode:      cfg.Mode,
+		dryRun:    cfg.DryRun,
+		logPath:   logPath,
+		seen:      map[string]bool{},
+		signal:    syscall.Kill,
+		processes: listProcesses,
+	}
+}
+
+// Check acts on new blocked CRITICAL events. The offending command is
+// found among the agent's descendant processes; commands that already
+// finished are recorded as not running.
+func (e *Enforcer) Check(agents []agent.Instance, events []agent.SecurityEvent, now time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var procs []process
+	listed := false
+
+	// Events leave the monitor's recent window for good, so only the keys
+	// still in it are worth remembering
+	current := map[string]bool{}
+	defer func() {
+		for key := range e.seen {
+			if !current[key] {
+				delete(e.seen, key)
+			}
+		}
+	}()
+
+	for _, evt := range events {
+		if evt.Severity != agent.SecSevCritical || !evt.Blocked {
+			continue
+		}
+		key := fmt.Sprintf("%d|%s|%s", evt.Timestamp.UnixNano(), evt.AgentID, evt.Detail)
+		current[key] = true
+		if e.seen[key] {
+			continue
+		}
+		e.seen[key] = true
+
+		if !listed {
+			var err error
+			if procs, err = e.processes(); err != nil {
+				procs = nil
+			}
+			listed = true
+		}
+
+		a := Action{
+			Timestamp: now,
+			AgentID:   evt.AgentID,
+			Command:   evt.Detail,
+			Reason:    evt.Description,
+			Mode:      e.mode,
+			Status:    StatusNotRunning,
+		}
+		for _, ag := range agents {
+			if evt.AgentID != "" && ag.Info.ID != evt.AgentID {
+				continue
+			}
+			matches := findChildren(procs, ag.PID, evt.Detail)
+			if len(matches) == 0 {
+				continue
+			}
+			a.AgentID, a.AgentName, a.AgentPID = ag.Info.ID, ag.Info.Name, ag.PID
+			if len(matches) > 1 {
+				a.Status = StatusAmbiguous
+				break
+			}
+			a.PID, a.Command = matches[0].pid, matches[0].args
+			break
+		}
+
+		if a.PID != 0 {
+			e.apply(&a)
+		}
+		e.record(a)
+	}
+}
+
+// apply signals the process or, in dry-run mode, only notes the intent
+func (e *Enforcer) apply(a *Action) {
+	if e.dryRun {
+		a.Status = StatusDryRun
+		return
+	}
+
+	// The PID may have exited and been reused since the process table was
+	// read; only signal it if it still runs the same command
+	if !e.stillRunning(a.PID, a.Command) {
+		a.Status = StatusNotRunning
+		return
+	}
+
+	sig, status := syscall.SIGSTOP, StatusPaused
+	if e.mode == ModeKill {
+		sig, status = syscall.SIGKILL, StatusKilled
+	}
+	if err := e.signal(a.PID, sig); err != nil {
+		a.Status, a.Error = StatusFailed, err.Error()
+		return
+	}
+	a.Status = status
+}
+
+// stillRunning re-reads the process table and reports whether pid runs args
+func (e *Enforcer) stillRunning(pid int, args string) bool {
+	procs, err := e.processes()
+	if err != nil {
+		return false
+	}
+	for _, p := range procs {
+		if p.pid == pid {
+			return p.args == args
+		}
+	}
+	return false
+}
+
+// Resume continues a paused process
+func (e *Enforcer) Resume(pid int) error {
+	return e.resolve(pid, syscall.SIGCONT, StatusResumed)
+}
+
+// Terminate kills a paused process. SIGKILL is used because a stopped
+// process does not handle SIGTERM until it is continued.
+func (e *Enforcer) Terminate(pid int) error {
+	return e.resolve(pid, syscall.SIGKILL, StatusTerminated)
+}
+
+func (e *Enforcer) resolve(pid int, sig syscall.Signal, status string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for i := range e.actions {
+		a := &e.actions[i]
+		if a.PID != pid || a.Status != StatusPaused {
+			continue
+		}
+		if err := e.signal(pid, sig); err != nil {
+			return fmt.Errorf("signalling PID %d: %w", pid, err)
+		}
+		a.Status = status
+		e.appendLog(*a)
+		return nil
+	}
+	return fmt.Errorf("no paused process with PID %d", pid)
+}
+
+// Actions returns recent actions, oldest first
+func (e *Enforcer) Actions() []Action {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]Action(nil), e.actions...)
+}
+
+// Alerts returns one critical alert per action, oldest first
+func (e *Enforcer) Alerts() []agent.Alert {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]agent.Alert(nil), e.alerts...)
+}
+
+// record keeps the action, raises an alert and appends it to the log
+func (e *Enforcer) record(a Action) {
+	e.actions = append(e.actions, a)
+	if len(e.actions) > maxActions {
+		e.actions = e.actions[len(e.actions)-maxActions:]
+	}
+
+	e.alerts = append(e.alerts, agent.Alert{
+		Timestamp: a.Timestamp,
+		Level:     agent.AlertCritical,
+		AgentID:   a.AgentID,
+		AgentName: a.AgentName,
+		Message:   describe(a),
+	})
+	if len(e.alerts) > maxActions {
+		e.alerts = e.alerts[len(e.alerts)-maxActions:]
+	}
+
+	e.appendLog(a)
+}
+
+// appendLog writes one JSON line; failures must not stop collection
+func (e *Enforcer) appendLog(a Action) {
+	if e.logPath == "" {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(e.logPath), 0o755); err != nil {
+		return
+	}
+	f, err := os.OpenFile(e.logPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	_ = json.NewEncoder(f).Encode(a)
+}
+
+// describe renders an action as an alert message
+func describe(a Action) string {
+	switch a.Status {
+	case StatusPaused:
+		return fmt.Sprintf("Paused PID %d (%s): %s — resume or terminate it", a.PID, a.Reason, a.Command)
+	case StatusKilled:
+		return fmt.Sprintf("Killed PID %d (%s): %s", a.PID, a.Reason, a.Command)
+	case StatusDryRun:
+		return fmt.Sprintf("Dry run: would %s PID %d (%s): %s", a.Mode, a.PID, a.Reason, a.Command)
+	case StatusFailed:
+		return fmt.Sprintf("Failed to %s PID %d (%s): %s", a.Mode, a.PID, a.Reason, a.Error)
+	case StatusAmbiguous:
+		return fmt.Sprintf("Not enforced: several processes of %s run the blocked command (%s): %s", a.AgentName, a.Reason, a.Command)
+	default:
+		return fmt.Sprintf("Blocked command already finished (%s): %s", a.Reason, a.Command)
+	}
+}
+
+// findChildren returns the descendants of root running command: the same
+// arguments, or the command wrapped in "<shell> -c". A detail cut short
+// with "..." matches commands starting with what is left of it.
+func findChildren(procs []process, root int, command string) []process {
+	children := map[int][]process{}
+	for _, p := range procs {
+		children[p.ppid] = append(children[p.ppid], p)
+	}
+
+	want := normalize(command)
+	truncated := false
+	for _, ellipsis := range []string{"...", "…"} {
+		if s, ok := strings.CutSuffix(want, ellipsis); ok {
+			want, truncated = strings.TrimSpace(s), true
+			break
+		}
+	}
+	if want == "" {
+		return nil
+	}
+
+	var matches []process
+	queue := []int{root}
+	for len(queue) > 0 {
+		pid := queue[0]
+		queue = queue[1:]
+		for _, p := range children[pid] {
+			args := normalize(p.args)
+			for _, cmd := range []string{args, shellCommand(args)} {
+				if cmd == want || (truncated && strings.HasPrefix(cmd, want)) {
+					matches = append(matches, p)
+					break
+				}
+			}
+			queue = append(queue, p.pid)
+		}
+	}
+	return matches
+}
+
+// shells run the command given after -c
+var shells = map[string]bool{"sh": true, "bash": true, "zsh": true, "dash": true, "fish": true}
+
+// shellCommand returns the command of "<shell> -c <command>", or ""
+func shellCommand(args string) string {
+	program, rest, _ := strings.Cut(args, " ")
+	if !shells[filepath.Base(program)] {
+		return ""
+	}
+	cmd, ok := strings.CutPrefix(rest, "-c ")
+	if !ok {
+		return ""
+	}
+	return cmd
+}
+
+func normalize(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// listProcesses reads pid, parent pid and arguments for every process
+func listProcesses() ([]process, error) {
+	out, err := exec.Command("ps", "-axo", "pid=,ppid=,args=").Output()
+	if err != nil {
+		return nil, fmt.Errorf("listing processes: %w", err)
+	}
+
+	var procs []process
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		pid, err1 := strconv.Atoi(fields[0])
+		ppid, err2 := strconv.Atoi(fields[1])
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		procs = append(procs, process{pid: pid, ppid: ppid, args: strings.Join(fields[2:], " ")})
+	}
+	return procs, nil
+}
@@ -0,0 +1,121 @@
+// Package groups clusters agents by the repository they work in
+package groups
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+)
+
+// Group is the aggregate of every agent working in one repository, or
+// one working directory when it isn't inside a repository
+type Group struct {
+	Root string `json:"root"`
+	Name string `json:"name"`
+	// Repo is false when Root is a plain working directory
+	Repo        bool    `json:"repo"`
+	Agents      int     `json:"agents"`
+	PIDs        []int   `json:"pids"`
+	TotalTokens int64   `json:"total_tokens"`
+	Cost        float64 `json:"cost_usd"`
+	LOCAdded    int     `json:"loc_added"`
+	LOCRemoved  int     `json:"loc_removed"`
+	// Uncommitted is counted once: agents in a group share the work tree
+	Uncommitted int `json:"uncommitted"`
+}
+
+// RepoRoot returns the closest directory at or above dir holding a .git
+// entry (a directory, or a file for worktrees and submodules)
+func RepoRoot(dir string) (string, bool) {
+	if dir == "" {
+		return "", false
+	}
+	dir = filepath.Clean(dir)
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// Resolver maps working directories to repository roots, remembering
+// each answer so refreshes don't walk the filesystem again
+type Resolver struct {
+	cache map[string]resolved
+}
+
+type resolved struct {
+	root string
+	repo bool
+}
+
+// NewResolver creates an empty Resolver
+func NewResolver() *Resolver {
+	return &Resolver{cache: map[string]resolved{}}
+}
+
+// Root returns the repository root of dir, falling back to dir itself
+func (r *Resolver) Root(dir string) (string, bool) {
+	if res, ok := r.cache[dir]; ok {
+		return res.root, res.repo
+	}
+	root, repo := RepoRoot(dir)
+	if !repo {
+		root = dir
+	}
+	r.cache[dir] = resolved{root: root, repo: repo}
+	return root, repo
+}
+
+// Build groups agents by repository root, ordered by name then root.
+// Agents without a working directory share the group with an empty root.
+func (r *Resolver) Build(agents []agent.Instance) []Group {
+	byRoot := map[string]*Group{}
+	for _, a := range agents {
+		root, repo := r.Root(a.WorkDir)
+		g, ok := byRoot[root]
+		if !ok {
+			g = &Group{Root: root, Name: groupName(root), Repo: repo}
+			byRoot[root] = g
+		}
+		g.Agents++
+		g.PIDs = append(g.PIDs, a.PID)
+		g.TotalTokens += a.Tokens.TotalTokens
+		g.Cost += a.Tokens.EstCost
+		g.LOCAdded += a.LOC.Added
+		g.LOCRemoved += a.LOC.Removed
+		g.Uncommitted = max(g.Uncommitted, a.Git.Uncommitted)
+	}
+
+	out := make([]Group, 0, len(byRoot))
+	for _, g := range byRoot {
+		out = append(out, *g)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Name != out[j].Name {
+			return out[i].Name < out[j].Name
+		}
+		return out[i].Root < out[j].Root
+	})
+	return out
+}
+
+// Build groups agents with a fresh Resolver
+func Build(agents []agent.Instance) []Group {
+	return NewResolver().Build(agents)
+}
+
+// groupName is the last path element, or "(no directory)"
+func groupName(root string) string {
+	if root == "" {
+		return "(no directory)"
+	}
+	return filepath.Base(root)
+}
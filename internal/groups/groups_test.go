@@ -0,0 +1,84 @@
+package groups
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+)
+
+func TestRepoRootFindsEnclosingRepository(t *testing.T) {
+	repo := t.TempDir()
+	if err := os.Mkdir(filepath.Join(repo, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	sub := filepath.Join(repo, "cmd", "tool")
+	if err := os.MkdirAll(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if root, ok := RepoRoot(sub); !ok || root != repo {
+		t.Fatalf("expected %s, got %q %v", repo, root, ok)
+	}
+
+	// A worktree's .git is a file
+	worktree := t.TempDir()
+	if err := os.WriteFile(filepath.Join(worktree, ".git"), []byte("gitdir: elsewhere\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if root, ok := RepoRoot(worktree); !ok || root != worktree {
+		t.Fatalf("expected worktree root %s, got %q %v", worktree, root, ok)
+	}
+}
+
+func TestBuildAggregatesPerRepository(t *testing.T) {
+	repo := t.TempDir()
+	if err := os.Mkdir(filepath.Join(repo, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	sub := filepath.Join(repo, "web")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	plain := t.TempDir()
+
+	claude := agent.Instance{PID: 1, WorkDir: repo}
+	claude.Tokens.TotalTokens = 1000
+	claude.Tokens.EstCost = 0.5
+	claude.LOC.Added = 10
+	claude.Git.Uncommitted = 3
+	copilot := agent.Instance{PID: 2, WorkDir: sub}
+	copilot.Tokens.TotalTokens = 500
+	copilot.Tokens.EstCost = 0.25
+	copilot.LOC.Removed = 4
+	copilot.Git.Uncommitted = 5
+	aider := agent.Instance{PID: 3, WorkDir: plain}
+
+	groups := Build([]agent.Instance{claude, copilot, aider})
+	if len(groups) != 2 {
+		t.Fatalf("expected 2 groups, got %+v", groups)
+	}
+
+	var g Group
+	for _, candidate := range groups {
+		if candidate.Root == repo {
+			g = candidate
+		}
+	}
+	if !g.Repo || g.Agents != 2 || len(g.PIDs) != 2 {
+		t.Fatalf("expected both repo agents in one group, got %+v", g)
+	}
+	if g.TotalTokens != 1500 || g.Cost != 0.75 || g.LOCAdded != 10 || g.LOCRemoved != 4 {
+		t.Fatalf("unexpected totals: %+v", g)
+	}
+	if g.Uncommitted != 5 {
+		t.Fatalf("uncommitted changes belong to the shared work tree, got %d", g.Uncommitted)
+	}
+
+	for _, candidate := range groups {
+		if candidate.Root == plain && candidate.Repo {
+			t.Fatalf("a plain directory is not a repository: %+v", candidate)
+		}
+	}
+}
@@ -0,0 +1,370 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+	"github.com/rafaelperezbeato/agentmetrics/internal/appconfig"
+	"github.com/rafaelperezbeato/agentmetrics/internal/budget"
+	"github.com/rafaelperezbeato/agentmetrics/internal/lifecycle"
+)
+
+// Event types a hook can be bound to
+const (
+	TypeAlert          = "alert"
+	TypeSecurity       = "security"
+	TypeAgentStart     = "agent_start"
+	TypeAgentStop      = "agent_stop"
+	TypeAgentStatus    = "agent_status"
+	TypeModelChanged   = "model_changed"
+	TypeBranchChanged  = "branch_changed"
+	TypeWorkDirChanged = "workdir_changed"
+	TypeBudgetExceeded = "budget_exceeded"
+)
+
+const (
+	defaultTimeout = 30 * time.Second
+	queueSize      = 64
+	// maxOutput caps the command output kept per run
+	maxOutput = 4096
+	// maxResults caps the runs kept in memory
+	maxResults = 20
+)
+
+// Event is what a hook command receives as JSON on stdin
+type Event struct {
+	Type      string    `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
+	AgentID   string    `json:"agent_id,omitempty"`
+	AgentName string    `json:"agent_name,omitempty"`
+	PID       int       `json:"pid,omitempty"`
+	WorkDir   string    `json:"workdir,omitempty"`
+	Level     string    `json:"level,omitempty"`
+	Category  string    `json:"category,omitempty"`
+	Message   string    `json:"message,omitempty"`
+	Detail    string    `json:"detail,omitempty"`
+	// Data is the underlying alert, security event, agent or budget usage
+	Data any `json:"data,omitempty"`
+}
+
+// FromAlert wraps a monitor alert
+func FromAlert(al agent.Alert) Event {
+	return Event{
+		Type:      TypeAlert,
+		Timestamp: al.Timestamp,
+		AgentID:   al.AgentID,
+		AgentName: al.AgentName,
+		Level:     string(al.Level),
+		Message:   al.Message,
+		Data:      al,
+	}
+}
+
+// FromSecurity wraps a security event
+func FromSecurity(evt agent.SecurityEvent) Event {
+	return Event{
+		Type:      TypeSecurity,
+		Timestamp: evt.Timestamp,
+		AgentID:   evt.AgentID,
+		AgentName: evt.AgentName,
+		Level:     string(evt.Severity),
+		Category:  string(evt.Category),
+		Message:   evt.Description,
+		Detail:    evt.Detail,
+		Data:      evt,
+	}
+}
+
+// lifecycleTypes maps lifecycle transitions to hook event types
+var lifecycleTypes = map[string]string{
+	lifecycle.Started: TypeAgentStart,
+	lifecycle.Exited:  TypeAgentStop,
+	lifecycle.Status:  TypeAgentStatus,
+	lifecycle.Model:   TypeModelChanged,
+	lifecycle.Branch:  TypeBranchChanged,
+	lifecycle.WorkDir: TypeWorkDirChanged,
+}
+
+// FromLifecycle wraps an agent transition. Category holds the new value,
+// e.g. the status the agent went to, and Detail the old one.
+func FromLifecycle(e lifecycle.Event) Event {
+	return Event{
+		Type:      lifecycleTypes[e.Type],
+		Timestamp: e.Timestamp,
+		AgentID:   e.AgentID,
+		AgentName: e.AgentName,
+		PID:       e.PID,
+		WorkDir:   e.WorkDir,
+		Category:  e.To,
+		Message:   e.Describe(),
+		Detail:    e.From,
+		Data:      e,
+	}
+}
+
+// FromBudget wraps a cap that was exceeded
+func FromBudget(u budget.Usage, now time.Time) Event {
+	return Event{
+		Type:      TypeBudgetExceeded,
+		Timestamp: now,
+		Level:     string(agent.AlertCritical),
+		Category:  u.Period,
+		Message:   fmt.Sprintf("Budget %q exceeded: $%.2f of $%.2f (%s)", u.Budget, u.Spent, u.Limit, u.Period),
+		Data:      u,
+	}
+}
+
+// alertRank and severityRank order levels from least to most urgent
+var (
+	alertRank    = map[string]int{"INFO": 1, "WARNING": 2, "CRITICAL": 3}
+	severityRank = map[string]int{"LOW": 1, "MEDIUM": 2, "HIGH": 3, "CRITICAL": 4}
+)
+
+// Matches reports whether an "on" selector covers e. Selectors are an
+// event type, optionally narrowed after a colon: "alert:warning" (that
+// level or above), "security:HIGH" (that severity or above),
+// "security:remote_access" (that category), "budget_exceeded:team" (that
+// budget), "agent_status:idle" (that new status or agent ID) or
+// "agent_stop:aider" (that agent ID).
+func Matches(on string, e Event) bool {
+	typ, qualifier, _ := strings.Cut(strings.TrimSpace(on), ":")
+	if typ != e.Type {
+		return false
+	}
+	if qualifier == "" {
+		return true
+	}
+
+	switch e.Type {
+	case TypeAlert:
+		want, ok := alertRank[strings.ToUpper(qualifier)]
+		return ok && alertRank[e.Level] >= want
+	case TypeSecurity:
+		if want, ok := severityRank[strings.ToUpper(qualifier)]; ok {
+			return severityRank[e.Level] >= want
+		}
+		return strings.EqualFold(qualifier, e.Category)
+	case TypeBudgetExceeded:
+		u, ok := e.Data.(budget.Usage)
+		return ok && strings.EqualFold(qualifier, u.Budget)
+	case TypeAgentStatus:
+		return strings.EqualFold(qualifier, e.Category) || strings.EqualFold(qualifier, e.AgentID)
+	default:
+		return strings.EqualFold(qualifier, e.AgentID)
+	}
+}
+
+// Result records one hook run
+type Result struct {
+	Hook     string        `json:"hook"`
+	Event    string        `json:"event"`
+	AgentID  string        `json:"agent_id,omitempty"`
+	Started  time.Time     `json:"started"`
+	Duration time.Duration `json:"duration_ns"`
+	ExitCode int           `json:"exit_code"`
+	Output   string        `json:"output,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// Failed reports whether the command timed out, could not start or
+// exited non-zero
+func (r Result) Failed() bool {
+	return r.Error != ""
+}
+
+// job is one hook to run for one event
+type job struct {
+	hook  appconfig.Hook
+	event Event
+}
+
+// Runner executes the configured hooks for matching events. Commands run
+// one at a time on a background worker, so Dispatch never blocks the
+// collection loop. It is safe for concurrent use.
+type Runner struct {
+	hooks   []appconfig.Hook
+	logPath string
+
+	queue chan job
+	wg    sync.WaitGroup
+
+	mu      sync.Mutex
+	results []Result
+}
+
+// New creates a runner that appends every run to logPath as JSON lines
+func New(cfgs []appconfig.Hook, logPath string) *Runner {
+	r := &Runner{
+		hooks:   cfgs,
+		logPath: logPath,
+		queue:   make(chan job, queueSize),
+	}
+	r.wg.Add(1)
+	go r.run()
+	return r
+}
+
+// Dispatch queues every hook matching each event. Events without an agent
+// PID or working directory are completed from the agent with the same ID.
+func (r *Runner) Dispatch(events []Event, agents []agent.Instance) {
+	for _, e := range events {
+		if e.PID == 0 && e.AgentID != "" {
+			for _, a := range agents {
+				if a.Info.ID == e.AgentID && (e.AgentName == "" || a.Info.Name == e.AgentName) {
+					e.PID, e.WorkDir = a.PID, a.WorkDir
+					break
+				}
+			}
+		}
+
+		for _, h := range r.hooks {
+			if !Matches(h.On, e) {
+				continue
+			}
+			select {
+			case r.queue <- job{hook: h, event: e}:
+			default:
+				r.record(Result{Hook: h.Name, Event: e.Type, AgentID: e.AgentID, Started: time.Now(), ExitCode: -1,
+					Error: "hook queue full, run skipped"})
+			}
+		}
+	}
+}
+
+// Results returns the most recent runs, oldest first
+func (r *Runner) Results() []Result {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Result(nil), r.results...)
+}
+
+// Close waits for queued hooks and stops the worker
+func (r *Runner) Close() {
+	close(r.queue)
+	r.wg.Wait()
+}
+
+func (r *Runner) run() {
+	defer r.wg.Done()
+	for j := range r.queue {
+		r.record(execute(j.hook, j.event))
+	}
+}
+
+// execute runs one hook with the event on stdin and in the environment
+func execute(h appconfig.Hook, e Event) Result {
+	res := Result{Hook: h.Name, Event: e.Type, AgentID: e.AgentID, Started: time.Now()}
+
+	payload, err := json.Marshal(e)
+	if err != nil {
+		res.ExitCode, res.Error = -1, fmt.Sprintf("encoding event: %v", err)
+		return res
+	}
+
+	timeout := h.Timeout.Duration()
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", h.Command)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Env = append(os.Environ(), env(h, e)...)
+	// Don't hang on pipes held open by background children after a kill
+	cmd.WaitDelay = time.Second
+	if info, err := os.Stat(e.WorkDir); err == nil && info.IsDir() {
+		cmd.Dir = e.WorkDir
+	}
+
+	out, err := cmd.CombinedOutput()
+	res.Duration = time.Since(res.Started)
+	res.Output = truncate(strings.TrimSpace(string(out)), maxOutput)
+
+	var exitErr *exec.ExitError
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		res.ExitCode, res.Error = -1, fmt.Sprintf("timed out after %s", timeout)
+	case errors.As(err, &exitErr):
+		res.ExitCode, res.Error = exitErr.ExitCode(), fmt.Sprintf("exit status %d", exitErr.ExitCode())
+	case err != nil:
+		res.ExitCode, res.Error = -1, err.Error()
+	}
+	return res
+}
+
+// env describes the event to commands that don't read stdin
+func env(h appconfig.Hook, e Event) []string {
+	vars := []string{
+		"AGENTMETRICS_HOOK=" + h.Name,
+		"AGENTMETRICS_EVENT=" + e.Type,
+		"AGENTMETRICS_TIMESTAMP=" + e.Timestamp.Format(time.RFC3339),
+		"AGENTMETRICS_AGENT_ID=" + e.AgentID,
+		"AGENTMETRICS_AGENT_NAME=" + e.AgentName,
+		"AGENTMETRICS_WORKDIR=" + e.WorkDir,
+		"AGENTMETRICS_LEVEL=" + e.Level,
+		"AGENTMETRICS_CATEGORY=" + e.Category,
+		"AGENTMETRICS_MESSAGE=" + e.Message,
+		"AGENTMETRICS_DETAIL=" + e.Detail,
+	}
+	if e.PID != 0 {
+		vars = append(vars, "AGENTMETRICS_PID="+strconv.Itoa(e.PID))
+	}
+	return vars
+}
+
+// record keeps the result in memory and appends it to the log
+func (r *Runner) record(res Result) {
+	r.mu.Lock()
+	r.results = append(r.results, res)
+	if len(r.results) > maxResults {
+		r.results = r.results[len(r.results)-maxResults:]
+	}
+	r.mu.Unlock()
+
+	r.appendLog(res)
+}
+
+// appendLog writes one JSON line; failures must not stop collection
+func (r *Runner) appendLog(res Result) {
+	if r.logPath == "" {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(r.logPath), 0o755); err != nil {
+		return
+	}
+	f, err := os.OpenFile(r.logPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	data, err := json.Marshal(res)
+	if err != nil {
+		return
+	}
+	_, _ = f.Write(append(data, '\n'))
+}
+
+// truncate cuts s to at most n bytes without splitting a UTF-8 sequence
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n] + "…"
+}
@@ -0,0 +1,113 @@
+package hooks
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+	"github.com/rafaelperezbeato/agentmetrics/internal/appconfig"
+	"github.com/rafaelperezbeato/agentmetrics/internal/budget"
+	"github.com/rafaelperezbeato/agentmetrics/internal/lifecycle"
+)
+
+func TestMatches(t *testing.T) {
+	warning := FromAlert(agent.Alert{Level: agent.AlertWarning, AgentID: "aider"})
+	ssh := FromSecurity(agent.SecurityEvent{Severity: agent.SecSevHigh, Category: "remote_access"})
+	stop := FromLifecycle(lifecycle.Event{Type: lifecycle.Exited, AgentID: "claude-code"})
+	idle := FromLifecycle(lifecycle.Event{Type: lifecycle.Status, AgentID: "aider", From: "Running", To: "Idle"})
+	team := FromBudget(budget.Usage{Budget: "team", Limit: 1, Spent: 2}, time.Now())
+
+	cases := []struct {
+		on   string
+		e    Event
+		want bool
+	}{
+		{"alert", warning, true},
+		{"alert:warning", warning, true},
+		{"alert:critical", warning, false},
+		{"security:HIGH", ssh, true},
+		{"security:critical", ssh, false},
+		{"security:remote_access", ssh, true},
+		{"security:sudo", ssh, false},
+		{"agent_stop", stop, true},
+		{"agent_stop:claude-code", stop, true},
+		{"agent_start", stop, false},
+		{"agent_status:idle", idle, true},
+		{"agent_status:aider", idle, true},
+		{"agent_status:running", idle, false},
+		{"budget_exceeded:team", team, true},
+		{"budget_exceeded:api", team, false},
+	}
+	for _, c := range cases {
+		if got := Matches(c.on, c.e); got != c.want {
+			t.Errorf("Matches(%q, %s) = %v, want %v", c.on, c.e.Type, got, c.want)
+		}
+	}
+}
+
+func TestRunnerPassesEventAndLogsFailures(t *testing.T) {
+	dir := t.TempDir()
+	workDir := filepath.Join(dir, "repo")
+	if err := os.Mkdir(workDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	logPath := filepath.Join(dir, "hooks.jsonl")
+
+	r := New([]appconfig.Hook{
+		{Name: "capture", On: "alert", Command: `cat > event.json; echo "$AGENTMETRICS_EVENT $AGENTMETRICS_PID"`},
+		{Name: "broken", On: "alert:critical", Command: "echo boom >&2; exit 3"},
+		{Name: "slow", On: "security", Command: "sleep 5", Timeout: appconfig.Duration(50 * time.Millisecond)},
+	}, logPath)
+
+	agents := []agent.Instance{{PID: 4242, WorkDir: workDir, Info: agent.Info{ID: "aider", Name: "Aider"}}}
+	r.Dispatch([]Event{
+		FromAlert(agent.Alert{Level: agent.AlertCritical, AgentID: "aider", AgentName: "Aider", Message: "idle"}),
+		FromSecurity(agent.SecurityEvent{Severity: agent.SecSevLow}),
+	}, agents)
+	r.Close()
+
+	results := r.Results()
+	if len(results) != 3 {
+		t.Fatalf("expected 3 runs, got %+v", results)
+	}
+	if results[0].Failed() || results[0].Output != "alert 4242" {
+		t.Fatalf("unexpected capture run: %+v", results[0])
+	}
+	if data, err := os.ReadFile(filepath.Join(workDir, "event.json")); err != nil || !strings.Contains(string(data), `"message":"idle"`) {
+		t.Fatalf("expected event JSON on stdin in the agent's workdir, got %q (%v)", data, err)
+	}
+	if !results[1].Failed() || results[1].ExitCode != 3 || results[1].Output != "boom" {
+		t.Fatalf("unexpected broken run: %+v", results[1])
+	}
+	if !results[2].Failed() || !strings.Contains(results[2].Error, "timed out") {
+		t.Fatalf("expected timeout, got %+v", results[2])
+	}
+
+	logged, err := os.ReadFile(logPath)
+	if err != nil || strings.Count(string(logged), "\n") != 3 {
+		t.Fatalf("expected 3 logged runs, got %q (%v)", logged, err)
+	}
+}
+
+func TestTruncateKeepsRunesWhole(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		n    int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"abcdef", 3, "abc…"},
+		{"aé", 2, "a…"},     // é is two bytes, the cut lands inside it
+		{"日本語", 4, "日…"},    // three-byte runes
+		{"ab日本", 5, "ab日…"}, // cut exactly on a boundary
+	} {
+		got := truncate(tc.in, tc.n)
+		if got != tc.want || !utf8.ValidString(got) {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tc.in, tc.n, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package lifecycle
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+)
+
+// Event types
+const (
+	Started = "started"
+	Exited  = "exited"
+	Status  = "status"
+	Model   = "model"
+	Branch  = "branch"
+	WorkDir = "workdir"
+)
+
+// maxEvents caps the events kept for display
+const maxEvents = 200
+
+// Event is one transition between two scans of the same agent process
+type Event struct {
+	Timestamp time.Time `json:"timestamp"`
+	Type      string    `json:"type"`
+	AgentID   string    `json:"agent_id"`
+	AgentName string    `json:"agent_name"`
+	PID       int       `json:"pid"`
+	WorkDir   string    `json:"workdir,omitempty"`
+	From      string    `json:"from,omitempty"`
+	To        string    `json:"to,omitempty"`
+}
+
+// Describe returns a one-line summary for timelines and notifications
+func (e Event) Describe() string {
+	switch e.Type {
+	case Started:
+		return fmt.Sprintf("%s started (PID %d)", e.AgentName, e.PID)
+	case Exited:
+		return fmt.Sprintf("%s exited (PID %d)", e.AgentName, e.PID)
+	case Status:
+		return fmt.Sprintf("%s %s → %s", e.AgentName, e.From, e.To)
+	case Model:
+		return fmt.Sprintf("%s switched model %s → %s", e.AgentName, e.From, e.To)
+	case Branch:
+		return fmt.Sprintf("%s switched branch %s → %s", e.AgentName, e.From, e.To)
+	case WorkDir:
+		return fmt.Sprintf("%s moved to %s", e.AgentName, e.To)
+	}
+	return fmt.Sprintf("%s %s", e.AgentName, e.Type)
+}
+
+// snapshot is what the tracker remembers of an agent between scans
+type snapshot struct {
+	id, name string
+	status   string
+	model    string
+	branch   string
+	workDir  string
+}
+
+func snapshotOf(a agent.Instance) snapshot {
+	return snapshot{
+		id:      a.Info.ID,
+		name:    a.Info.Name,
+		status:  a.Status.String(),
+		model:   a.Tokens.LastModel,
+		branch:  a.Git.Branch,
+		workDir: a.WorkDir,
+	}
+}
+
+// Tracker diffs successive scans into lifecycle events. Agents are keyed
+// by PID; agents present at the first scan are the baseline and are not
+// reported as started.
+type Tracker struct {
+	prev   map[int]snapshot
+	events []Event
+}
+
+// NewTracker creates an empty tracker
+func NewTracker() *Tracker {
+	return &Tracker{}
+}
+
+// Observe compares agents with the previous scan and returns the new
+// events, oldest first
+func (t *Tracker) Observe(agents []agent.Instance, now time.Time) []Event {
+	current := make(map[int]snapshot, len(agents))
+	for _, a := range agents {
+		current[a.PID] = snapshotOf(a)
+	}
+	if t.prev == nil {
+		t.prev = current
+		return nil
+	}
+
+	var events []Event
+	for _, a := range agents {
+		cur := current[a.PID]
+		base := Event{Timestamp: now, AgentID: cur.id, AgentName: cur.name, PID: a.PID, WorkDir: cur.workDir}
+
+		old, ok := t.prev[a.PID]
+		if !ok {
+			e := base
+			e.Type, e.To = Started, cur.status
+			events = append(events, e)
+			continue
+		}
+		// Values that were not known yet (model, branch) are not a switch
+		for _, c := range []struct{ typ, from, to string }{
+			{Status, old.status, cur.status},
+			{Model, old.model, cur.model},
+			{Branch, old.branch, cur.branch},
+			{WorkDir, old.workDir, cur.workDir},
+		} {
+			if c.from != "" && c.to != "" && c.from != c.to {
+				e := base
+				e.Type, e.From, e.To = c.typ, c.from, c.to
+				events = append(events, e)
+			}
+		}
+	}
+	for pid, old := range t.prev {
+		if _, ok := current[pid]; !ok {
+			events = append(events, Event{
+				Timestamp: now, Type: Exited, AgentID: old.id, AgentName: old.name,
+				PID: pid, WorkDir: old.workDir, From: old.status,
+			})
+		}
+	}
+	t.prev = current
+
+	t.events = append(t.events, events...)
+	if len(t.events) > maxEvents {
+		t.events = t.events[len(t.events)-maxEvents:]
+	}
+	return events
+}
+
+// Events returns the most recent events, oldest first
+func (t *Tracker) Events() []Event {
+	return append([]Event(nil), t.events...)
+}
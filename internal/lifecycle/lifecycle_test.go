@@ -0,0 +1,59 @@
+package lifecycle
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+)
+
+func TestObserveDiffsScans(t *testing.T) {
+	tr := NewTracker()
+	now := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+
+	claude := agent.Instance{PID: 1, Status: agent.StatusRunning, WorkDir: "/src/api", Info: agent.Info{ID: "claude-code", Name: "Claude Code"}}
+	claude.Tokens.LastModel = "sonnet"
+	claude.Git.Branch = "main"
+	aider := agent.Instance{PID: 2, Status: agent.StatusRunning, Info: agent.Info{ID: "aider", Name: "Aider"}}
+
+	if events := tr.Observe([]agent.Instance{claude, aider}, now); len(events) != 0 {
+		t.Fatalf("first scan is the baseline, got %+v", events)
+	}
+
+	changed := claude
+	changed.Status = agent.StatusIdle
+	changed.Tokens.LastModel = "opus"
+	changed.Git.Branch = "fix"
+	copilot := agent.Instance{PID: 3, Info: agent.Info{ID: "copilot", Name: "Copilot"}}
+
+	events := tr.Observe([]agent.Instance{changed, copilot}, now.Add(time.Second))
+	want := []struct{ typ, from, to string }{
+		{Status, agent.StatusRunning.String(), agent.StatusIdle.String()},
+		{Model, "sonnet", "opus"},
+		{Branch, "main", "fix"},
+		{Started, "", ""},
+		{Exited, agent.StatusRunning.String(), ""},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("expected %d events, got %+v", len(want), events)
+	}
+	for i, w := range want {
+		e := events[i]
+		if e.Type != w.typ || e.From != w.from || (w.to != "" && e.To != w.to) {
+			t.Errorf("event %d: got %+v, want %s %s→%s", i, e, w.typ, w.from, w.to)
+		}
+	}
+	if events[4].PID != 2 || events[4].AgentName != "Aider" {
+		t.Fatalf("exit should describe the vanished agent, got %+v", events[4])
+	}
+
+	// Learning the model later is not a switch
+	late := copilot
+	late.Tokens.LastModel = "gpt-4o"
+	if events := tr.Observe([]agent.Instance{changed, late}, now.Add(2*time.Second)); len(events) != 0 {
+		t.Fatalf("expected no events, got %+v", events)
+	}
+	if len(tr.Events()) != 5 {
+		t.Fatalf("expected 5 retained events, got %d", len(tr.Events()))
+	}
+}
@@ -0,0 +1,351 @@
+package notify
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rafaelperezbeato/agentmetrics/internal/appconfig"
+	"github.com/rafaelperezbeato/agentmetrics/internal/collector"
+)
+
+const (
+	desktopQueueSize = 16
+	// maxGroupLines caps the events listed in one grouped notification
+	maxGroupLines = 5
+)
+
+// desktopMessage is one notification as shown by the desktop
+type desktopMessage struct {
+	// group is the agent the message belongs to; the desktop replaces the
+	// group's previous notification instead of stacking a new one
+	group    string
+	summary  string
+	body     string
+	critical bool
+}
+
+// Desktop shows critical events as freedesktop notifications, over D-Bus
+// via gdbus or with notify-send. Events are grouped per agent: at most one
+// notification per agent is shown per interval, listing everything that
+// happened since the previous one. It implements collector.Sink.
+//
+// Notifications shown over D-Bus can be clicked: a gdbus monitor watches
+// for their default action and runs on_click and the OnClick handlers.
+type Desktop struct {
+	cfg       appconfig.DesktopConfig
+	interval  time.Duration
+	watermark collector.Watermark
+
+	pending  map[string][]Notification
+	lastSent map[string]time.Time
+
+	queue chan desktopMessage
+	wg    sync.WaitGroup
+	send  func(desktopMessage) error
+	ids   map[string]uint32
+
+	// The click monitor is started with the first D-Bus notification
+	monitorOnce sync.Once
+	monitor     *exec.Cmd
+	monitorWG   sync.WaitGroup
+
+	mu      sync.Mutex
+	lastErr error
+	// clicks maps the notification IDs shown to their group
+	clicks  map[uint32]string
+	onClick []func(agent string)
+	// runClick runs the on_click command; replaced in tests
+	runClick func(agent string) error
+}
+
+// NewDesktop creates the notifier and starts its worker
+func NewDesktop(cfg appconfig.DesktopConfig) *Desktop {
+	d := &Desktop{
+		cfg:      cfg,
+		interval: cfg.Interval.Duration(),
+		pending:  map[string][]Notification{},
+		lastSent: map[string]time.Time{},
+		queue:    make(chan desktopMessage, desktopQueueSize),
+		ids:      map[string]uint32{},
+		clicks:   map[uint32]string{},
+	}
+	if d.interval <= 0 {
+		d.interval = 30 * time.Second
+	}
+	d.send = d.notify
+	d.runClick = d.runOnClick
+	d.wg.Add(1)
+	go d.run()
+	return d
+}
+
+// Publish queues matching events and shows what is due
+func (d *Desktop) Publish(st collector.State) {
+	d.publish(st, time.Now())
+}
+
+func (d *Desktop) publish(st collector.State, now time.Time) {
+	alerts, events := d.watermark.NewEvents(st)
+	for _, n := range Collect(alerts, collector.Unreviewed(st, events)) {
+		if n.AtLeast(d.cfg.MinLevel, d.cfg.MinSeverity) {
+			group := n.AgentName
+			if group == "" {
+				group = n.AgentID
+			}
+			d.pending[group] = append(d.pending[group], n)
+		}
+	}
+
+	// Groups still inside their interval wait for a later cycle
+	for group, ns := range d.pending {
+		if now.Sub(d.lastSent[group]) < d.interval {
+			continue
+		}
+		delete(d.pending, group)
+		d.lastSent[group] = now
+
+		select {
+		case d.queue <- groupMessage(group, ns):
+		default:
+			d.setErr(fmt.Errorf("desktop notification queue full, dropped %s", group))
+		}
+	}
+}
+
+// groupMessage folds one agent's pending events into one notification
+func groupMessage(group string, ns []Notification) desktopMessage {
+	msg := desktopMessage{group: group}
+	for _, n := range ns {
+		if n.Level == "CRITICAL" {
+			msg.critical = true
+		}
+	}
+
+	if len(ns) == 1 {
+		n := ns[0]
+		msg.summary = n.Title
+		msg.body = n.Message
+		if n.Detail != "" {
+			msg.body += "\n" + n.Detail
+		}
+		if n.Blocked {
+			msg.body += "\n[BLOCKED]"
+		}
+		return msg
+	}
+
+	msg.summary = fmt.Sprintf("%s: %d events", group, len(ns))
+	var lines []string
+	for i, n := range ns {
+		if i == maxGroupLines {
+			lines = append(lines, fmt.Sprintf("…and %d more", len(ns)-maxGroupLines))
+			break
+		}
+		line := fmt.Sprintf("%s [%s] %s", n.Timestamp.Format("15:04:05"), n.Level, n.Message)
+		if n.Detail != "" {
+			line += " — " + n.Detail
+		}
+		lines = append(lines, line)
+	}
+	msg.body = strings.Join(lines, "\n")
+	return msg
+}
+
+// OnClick registers fn to run with the agent's name or ID when one of its
+// notifications is clicked, e.g. for the TUI to open that agent
+func (d *Desktop) OnClick(fn func(agent string)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.onClick = append(d.onClick, fn)
+}
+
+// Close shows queued notifications and stops the worker and the click
+// monitor
+func (d *Desktop) Close() {
+	close(d.queue)
+	d.wg.Wait()
+	if d.monitor != nil {
+		_ = d.monitor.Process.Kill()
+		d.monitorWG.Wait()
+	}
+}
+
+// Name identifies the notifier in error reports
+func (d *Desktop) Name() string {
+	return "desktop notifications"
+}
+
+// LastError returns the most recent delivery error, if any
+func (d *Desktop) LastError() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.lastErr
+}
+
+// setErr records the outcome of the latest notification or click; nil
+// clears an earlier failure
+func (d *Desktop) setErr(err error) {
+	d.mu.Lock()
+	d.lastErr = err
+	d.mu.Unlock()
+}
+
+func (d *Desktop) run() {
+	defer d.wg.Done()
+	for msg := range d.queue {
+		d.setErr(d.send(msg))
+	}
+}
+
+// notify shows msg with the configured command, gdbus or notify-send
+func (d *Desktop) notify(msg desktopMessage) error {
+	if d.cfg.Command != "" {
+		return runNotifyCommand(d.cfg.Command, msg)
+	}
+	if _, err := exec.LookPath("gdbus"); err == nil {
+		return d.notifyDBus(msg)
+	}
+	if _, err := exec.LookPath("notify-send"); err == nil {
+		return runNotifyCommand("notify-send", msg)
+	}
+	return errors.New("desktop notifications need gdbus or notify-send")
+}
+
+// notifyIDPattern extracts the notification ID from gdbus's reply,
+// e.g. "(uint32 42,)"
+var notifyIDPattern = regexp.MustCompile(`uint32 (\d+)`)
+
+// actionPattern matches a clicked notification in gdbus monitor output,
+// e.g. "...Notifications.ActionInvoked (uint32 42, 'default')"
+var actionPattern = regexp.MustCompile(`\.ActionInvoked \(uint32 (\d+), '([^']*)'\)`)
+
+// notifyDBus calls org.freedesktop.Notifications.Notify, replacing the
+// group's previous notification
+func (d *Desktop) notifyDBus(msg desktopMessage) error {
+	urgency := 1
+	if msg.critical {
+		urgency = 2
+	}
+	out, err := exec.Command("gdbus", "call", "--session",
+		"--dest", "org.freedesktop.Notifications",
+		"--object-path", "/org/freedesktop/Notifications",
+		"--method", "org.freedesktop.Notifications.Notify",
+		gvariantString("agentmetrics"),
+		strconv.FormatUint(uint64(d.ids[msg.group]), 10),
+		gvariantString("dialog-warning"),
+		gvariantString(msg.summary),
+		gvariantString(msg.body),
+		"['default', 'Show details']",
+		fmt.Sprintf("{'urgency': <byte %d>}", urgency),
+		"-1",
+	).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("gdbus notify: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	if m := notifyIDPattern.FindSubmatch(out); m != nil {
+		if id, err := strconv.ParseUint(string(m[1]), 10, 32); err == nil {
+			d.ids[msg.group] = uint32(id)
+			d.mu.Lock()
+			d.clicks[uint32(id)] = msg.group
+			d.mu.Unlock()
+			d.monitorOnce.Do(d.watchClicks)
+		}
+	}
+	return nil
+}
+
+// watchClicks follows the notification service's signals with gdbus
+// monitor until Close
+func (d *Desktop) watchClicks() {
+	cmd := exec.Command("gdbus", "monitor", "--session",
+		"--dest", "org.freedesktop.Notifications",
+		"--object-path", "/org/freedesktop/Notifications")
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		d.setErr(fmt.Errorf("gdbus monitor: %w", err))
+		return
+	}
+	if err := cmd.Start(); err != nil {
+		d.setErr(fmt.Errorf("gdbus monitor: %w", err))
+		return
+	}
+	d.monitor = cmd
+
+	d.monitorWG.Add(1)
+	go func() {
+		defer d.monitorWG.Done()
+		sc := bufio.NewScanner(out)
+		for sc.Scan() {
+			d.handleSignal(sc.Text())
+		}
+		_ = cmd.Wait()
+	}()
+}
+
+// handleSignal acts on a click on one of this notifier's notifications;
+// other signals and other programs' notifications are ignored
+func (d *Desktop) handleSignal(line string) {
+	m := actionPattern.FindStringSubmatch(line)
+	if m == nil {
+		return
+	}
+	id, err := strconv.ParseUint(m[1], 10, 32)
+	if err != nil {
+		return
+	}
+
+	d.mu.Lock()
+	group, ok := d.clicks[uint32(id)]
+	handlers := slices.Clone(d.onClick)
+	d.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	if d.cfg.OnClick != "" {
+		d.setErr(d.runClick(group))
+	}
+	for _, fn := range handlers {
+		fn(group)
+	}
+}
+
+// runOnClick runs the on_click command for a clicked notification
+func (d *Desktop) runOnClick(group string) error {
+	cmd := exec.Command("sh", "-c", d.cfg.OnClick)
+	cmd.Env = append(os.Environ(), "AGENTMETRICS_AGENT_NAME="+group)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("on_click: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// runNotifyCommand runs a notify-send compatible command
+func runNotifyCommand(command string, msg desktopMessage) error {
+	urgency := "normal"
+	if msg.critical {
+		urgency = "critical"
+	}
+	out, err := exec.Command(command, "-a", "agentmetrics", "-u", urgency, msg.summary, msg.body).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s: %w: %s", command, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// gvariantString quotes s in GVariant text format so gdbus never parses
+// it as another type
+func gvariantString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `'`, `\'`)
+	return "'" + s + "'"
+}
@@ -0,0 +1,86 @@
+package notify
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+	"github.com/rafaelperezbeato/agentmetrics/internal/appconfig"
+	"github.com/rafaelperezbeato/agentmetrics/internal/collector"
+)
+
+func TestDesktopGroupsPerAgentWithinInterval(t *testing.T) {
+	d := NewDesktop(appconfig.DesktopConfig{
+		Enabled:     true,
+		MinLevel:    "CRITICAL",
+		MinSeverity: "HIGH",
+		Interval:    appconfig.Duration(time.Minute),
+	})
+	var mu sync.Mutex
+	var shown []desktopMessage
+	d.send = func(msg desktopMessage) error {
+		mu.Lock()
+		defer mu.Unlock()
+		shown = append(shown, msg)
+		return nil
+	}
+
+	start := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	event := func(at time.Time, sev agent.SecuritySeverity, detail string) collector.State {
+		return collector.State{SecurityEvents: []agent.SecurityEvent{{
+			Timestamp: at, Severity: sev, AgentName: "Aider", Description: "Dangerous", Detail: detail,
+		}}}
+	}
+
+	d.publish(event(start, agent.SecSevCritical, "rm -rf /"), start)
+	// Inside the interval: held back and grouped
+	d.publish(event(start.Add(time.Second), agent.SecSevHigh, "sudo su"), start.Add(time.Second))
+	d.publish(event(start.Add(2*time.Second), agent.SecSevLow, "ls"), start.Add(2*time.Second))
+	d.publish(event(start.Add(3*time.Second), agent.SecSevHigh, "ssh prod"), start.Add(3*time.Second))
+	d.publish(collector.State{}, start.Add(time.Minute))
+	d.Close()
+
+	if len(shown) != 2 {
+		t.Fatalf("expected 2 notifications, got %+v", shown)
+	}
+	if !shown[0].critical || !strings.Contains(shown[0].body, "rm -rf /") {
+		t.Fatalf("unexpected first notification: %+v", shown[0])
+	}
+	grouped := shown[1]
+	if grouped.summary != "Aider: 2 events" || grouped.critical ||
+		!strings.Contains(grouped.body, "sudo su") || !strings.Contains(grouped.body, "ssh prod") {
+		t.Fatalf("unexpected grouped notification: %+v", grouped)
+	}
+}
+
+func TestGVariantStringEscapesQuotes(t *testing.T) {
+	if got := gvariantString(`it's C:\tmp`); got != `'it\'s C:\\tmp'` {
+		t.Fatalf("unexpected quoting: %s", got)
+	}
+}
+
+func TestDesktopClickRunsHandlersForOwnNotifications(t *testing.T) {
+	d := NewDesktop(appconfig.DesktopConfig{Enabled: true, OnClick: "raise-terminal"})
+	defer d.Close()
+	d.clicks[42] = "Aider"
+
+	var ran, clicked []string
+	d.runClick = func(agent string) error {
+		ran = append(ran, agent)
+		return nil
+	}
+	d.OnClick(func(agent string) { clicked = append(clicked, agent) })
+
+	d.handleSignal("/org/freedesktop/Notifications: org.freedesktop.Notifications.NotificationClosed (uint32 42, uint32 2)")
+	d.handleSignal("/org/freedesktop/Notifications: org.freedesktop.Notifications.ActionInvoked (uint32 7, 'default')")
+	if len(ran) != 0 || len(clicked) != 0 {
+		t.Fatalf("only clicks on our notifications count, got %v %v", ran, clicked)
+	}
+
+	d.handleSignal("/org/freedesktop/Notifications: org.freedesktop.Notifications.ActionInvoked (uint32 42, 'default')")
+	if len(ran) != 1 || ran[0] != "Aider" || len(clicked) != 1 || clicked[0] != "Aider" {
+		t.Fatalf("expected on_click and the handler for Aider, got %v %v", ran, clicked)
+	}
+}
@@ -0,0 +1,129 @@
+package notify
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+)
+
+// Notification kinds
+const (
+	KindAlert    = "alert"
+	KindSecurity = "security"
+)
+
+// Notification is the common shape of an alert or security event sent to
+// external targets
+type Notification struct {
+	Kind      string    `json:"kind"`
+	Level     string    `json:"level"`
+	Category  string    `json:"category,omitempty"`
+	AgentID   string    `json:"agent_id"`
+	AgentName string    `json:"agent_name"`
+	Title     string    `json:"title"`
+	Message   string    `json:"message"`
+	Detail    string    `json:"detail,omitempty"`
+	Blocked   bool      `json:"blocked,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// FromAlert converts a monitor alert
+func FromAlert(al agent.Alert) Notification {
+	return Notification{
+		Kind:      KindAlert,
+		Level:     string(al.Level),
+		AgentID:   al.AgentID,
+		AgentName: al.AgentName,
+		Title:     fmt.Sprintf("[%s] %s", al.Level, al.AgentName),
+		Message:   al.Message,
+		Timestamp: al.Timestamp,
+	}
+}
+
+// FromSecurity converts a security event
+func FromSecurity(evt agent.SecurityEvent) Notification {
+	return Notification{
+		Kind:      KindSecurity,
+		Level:     string(evt.Severity),
+		Category:  string(evt.Category),
+		AgentID:   evt.AgentID,
+		AgentName: evt.AgentName,
+		Title:     fmt.Sprintf("[%s] %s: %s", evt.Severity, evt.AgentName, evt.Description),
+		Message:   evt.Description,
+		Detail:    evt.Detail,
+		Blocked:   evt.Blocked,
+		Timestamp: evt.Timestamp,
+	}
+}
+
+// Collect converts alerts and security events, in time order
+func Collect(alerts []agent.Alert, events []agent.SecurityEvent) []Notification {
+	out := make([]Notification, 0, len(alerts)+len(events))
+	for _, al := range alerts {
+		out = append(out, FromAlert(al))
+	}
+	for _, evt := range events {
+		out = append(out, FromSecurity(evt))
+	}
+	slices.SortStableFunc(out, func(a, b Notification) int {
+		return a.Timestamp.Compare(b.Timestamp)
+	})
+	return out
+}
+
+// Key identifies repeats of the same notification
+func (n Notification) Key() string {
+	return strings.Join([]string{n.Kind, n.AgentID, n.Level, n.Category, n.Message, n.Detail}, "|")
+}
+
+// alertRank and severityRank order levels from least to most urgent
+var (
+	alertRank    = map[string]int{"INFO": 1, "WARNING": 2, "CRITICAL": 3}
+	severityRank = map[string]int{"LOW": 1, "MEDIUM": 2, "HIGH": 3, "CRITICAL": 4}
+)
+
+// AtLeast reports whether n is at or above the minimum alert level or
+// security severity. An empty minimum lets everything through; an
+// unknown one lets nothing through.
+func (n Notification) AtLeast(minLevel, minSeverity string) bool {
+	rank, min := alertRank, minLevel
+	if n.Kind == KindSecurity {
+		rank, min = severityRank, minSeverity
+	}
+	if min == "" {
+		return true
+	}
+	want, ok := rank[strings.ToUpper(min)]
+	return ok && rank[n.Level] >= want
+}
+
+// Deduper drops repeats of a notification within a cooldown window
+type Deduper struct {
+	cooldown time.Duration
+	last     map[string]time.Time
+}
+
+// NewDeduper creates a deduper; a zero cooldown lets every repeat through
+func NewDeduper(cooldown time.Duration) *Deduper {
+	return &Deduper{cooldown: cooldown, last: map[string]time.Time{}}
+}
+
+// Allow records n and reports whether it should be sent
+func (d *Deduper) Allow(n Notification, now time.Time) bool {
+	key := n.Key()
+	if last, ok := d.last[key]; ok && now.Sub(last) < d.cooldown {
+		return false
+	}
+	d.last[key] = now
+
+	// Forget keys that can no longer suppress anything
+	for k, t := range d.last {
+		if now.Sub(t) >= d.cooldown {
+			delete(d.last, k)
+		}
+	}
+	return true
+}
@@ -0,0 +1,228 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/rafaelperezbeato/agentmetrics/internal/appconfig"
+	"github.com/rafaelperezbeato/agentmetrics/internal/collector"
+)
+
+// webhookQueueSize is the backlog each target may build up
+const webhookQueueSize = 64
+
+// webhookTarget is one configured endpoint with its parsed template and
+// its own queue of request bodies
+type webhookTarget struct {
+	cfg     appconfig.Webhook
+	tmpl    *template.Template
+	dedup   *Deduper
+	backoff time.Duration
+	queue   chan []byte
+	// err is the target's latest failure, cleared by a delivery that
+	// succeeds; guarded by Webhooks.mu
+	err error
+}
+
+// Webhooks posts new alerts and security events to the configured URLs.
+// It implements collector.Sink; each target has its own background worker,
+// so Publish never blocks the collection loop and a slow or retrying
+// endpoint doesn't hold up the others.
+type Webhooks struct {
+	targets   []*webhookTarget
+	client    *http.Client
+	watermark collector.Watermark
+
+	wg sync.WaitGroup
+
+	mu   sync.Mutex
+	errs []error
+}
+
+// NewWebhooks creates the sender and starts a worker per target. Targets
+// without a cooldown fall back to defaultCooldown, the alert monitor's
+// cooldown. Targets with an invalid template are skipped and reported by
+// Errors.
+func NewWebhooks(cfgs []appconfig.Webhook, defaultCooldown time.Duration) *Webhooks {
+	w := &Webhooks{
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, cfg := range cfgs {
+		t := &webhookTarget{
+			cfg:     cfg,
+			backoff: cfg.Backoff.Duration(),
+			queue:   make(chan []byte, webhookQueueSize),
+		}
+		if t.backoff <= 0 {
+			t.backoff = time.Second
+		}
+		cooldown := cfg.Cooldown.Duration()
+		if cooldown <= 0 {
+			cooldown = defaultCooldown
+		}
+		t.dedup = NewDeduper(cooldown)
+
+		if cfg.Template != "" {
+			tmpl, err := template.New(cfg.Name).Funcs(template.FuncMap{"json": toJSON}).Parse(cfg.Template)
+			if err != nil {
+				w.errs = append(w.errs, fmt.Errorf("webhook %q: %w", cfg.Name, err))
+				continue
+			}
+			t.tmpl = tmpl
+		}
+		w.targets = append(w.targets, t)
+	}
+
+	for _, t := range w.targets {
+		w.wg.Add(1)
+		go w.run(t)
+	}
+	return w
+}
+
+// toJSON lets templates embed values as JSON literals, e.g.
+// {"text": {{json .Title}}}
+func toJSON(v any) (string, error) {
+	data, err := json.Marshal(v)
+	return string(data), err
+}
+
+// Errors returns configuration problems found at construction
+func (w *Webhooks) Errors() []error {
+	return w.errs
+}
+
+// Publish queues every new event for each target that wants it
+func (w *Webhooks) Publish(st collector.State) {
+	alerts, events := w.watermark.NewEvents(st)
+	now := time.Now()
+	for _, n := range Collect(alerts, collector.Unreviewed(st, events)) {
+		for _, t := range w.targets {
+			if !t.wants(n) || !t.dedup.Allow(n, now) {
+				continue
+			}
+			body, err := t.render(n)
+			if err != nil {
+				w.setErr(t, err)
+				continue
+			}
+			select {
+			case t.queue <- body:
+			default:
+				w.setErr(t, fmt.Errorf("webhook queue full, dropped notification for %q", t.cfg.Name))
+			}
+		}
+	}
+}
+
+// Close flushes queued requests and stops the workers
+func (w *Webhooks) Close() {
+	for _, t := range w.targets {
+		close(t.queue)
+	}
+	w.wg.Wait()
+}
+
+// Name identifies the sender in error reports
+func (w *Webhooks) Name() string {
+	return "webhooks"
+}
+
+// LastError returns the latest error of every target whose last delivery
+// failed, or nil once they all succeed again
+func (w *Webhooks) LastError() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var errs []error
+	for _, t := range w.targets {
+		errs = append(errs, t.err)
+	}
+	return errors.Join(errs...)
+}
+
+// setErr records the outcome of t's latest delivery; nil clears it
+func (w *Webhooks) setErr(t *webhookTarget, err error) {
+	w.mu.Lock()
+	t.err = err
+	w.mu.Unlock()
+}
+
+// run delivers one target's queue in order
+func (w *Webhooks) run(t *webhookTarget) {
+	defer w.wg.Done()
+	for body := range t.queue {
+		w.setErr(t, w.deliver(t, body))
+	}
+}
+
+// deliver posts with exponential backoff between attempts. Client errors
+// (4xx other than 429) are not retried.
+func (w *Webhooks) deliver(t *webhookTarget, body []byte) error {
+	wait := t.backoff
+	var err error
+	for attempt := 0; attempt <= t.cfg.Retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(wait)
+			wait *= 2
+		}
+		var retry bool
+		if retry, err = w.post(t, body); err == nil || !retry {
+			return err
+		}
+	}
+	return err
+}
+
+// post sends one request and reports whether a failure is worth retrying
+func (w *Webhooks) post(t *webhookTarget, body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, t.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("webhook %q: building request: %w", t.cfg.Name, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range t.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("webhook %q: %w", t.cfg.Name, err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		retry := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		return retry, fmt.Errorf("webhook %q: server returned %s", t.cfg.Name, resp.Status)
+	}
+	return false, nil
+}
+
+// wants applies the target's level and agent filters
+func (t *webhookTarget) wants(n Notification) bool {
+	if len(t.cfg.Agents) > 0 && !slices.Contains(t.cfg.Agents, n.AgentID) {
+		return false
+	}
+	return n.AtLeast(t.cfg.MinLevel, t.cfg.MinSeverity)
+}
+
+// render builds the request body: the template output, or the
+// notification as JSON
+func (t *webhookTarget) render(n Notification) ([]byte, error) {
+	if t.tmpl == nil {
+		return json.Marshal(n)
+	}
+	var buf bytes.Buffer
+	if err := t.tmpl.Execute(&buf, n); err != nil {
+		return nil, fmt.Errorf("webhook %q: rendering template: %w", t.cfg.Name, err)
+	}
+	return buf.Bytes(), nil
+}
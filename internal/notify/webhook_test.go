@@ -0,0 +1,176 @@
+package notify
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+	"github.com/rafaelperezbeato/agentmetrics/internal/appconfig"
+	"github.com/rafaelperezbeato/agentmetrics/internal/collector"
+)
+
+// receiver is a stand-in webhook endpoint that fails the first failures
+// requests with 503
+type receiver struct {
+	mu       sync.Mutex
+	failures int
+	attempts int
+	bodies   []string
+	headers  http.Header
+}
+
+func newReceiver(t *testing.T, failures int) (*receiver, *httptest.Server) {
+	t.Helper()
+	rc := &receiver{failures: failures}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		rc.mu.Lock()
+		defer rc.mu.Unlock()
+		rc.attempts++
+		if rc.attempts <= rc.failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		rc.bodies = append(rc.bodies, string(body))
+		rc.headers = r.Header.Clone()
+	}))
+	t.Cleanup(srv.Close)
+	return rc, srv
+}
+
+func TestWebhookRetriesAndRendersTemplate(t *testing.T) {
+	rc, srv := newReceiver(t, 2)
+	w := NewWebhooks([]appconfig.Webhook{{
+		Name:     "chat",
+		URL:      srv.URL,
+		Headers:  map[string]string{"Authorization": "Bearer test"},
+		Template: `{"text": {{json .Title}}}`,
+		Retries:  2,
+		Backoff:  appconfig.Duration(time.Millisecond),
+	}}, time.Minute)
+
+	w.Publish(collector.State{SecurityEvents: []agent.SecurityEvent{{
+		Timestamp: time.Now(), Severity: agent.SecSevCritical, AgentName: "Aider", Description: `rm -rf "/"`,
+	}}})
+	w.Close()
+
+	if err := w.LastError(); err != nil {
+		t.Fatalf("expected delivery after retries, got %v", err)
+	}
+	if rc.attempts != 3 || len(rc.bodies) != 1 {
+		t.Fatalf("expected 3 attempts and 1 delivery, got %d and %d", rc.attempts, len(rc.bodies))
+	}
+	var doc map[string]string
+	if err := json.Unmarshal([]byte(rc.bodies[0]), &doc); err != nil {
+		t.Fatalf("template produced invalid JSON %q: %v", rc.bodies[0], err)
+	}
+	if doc["text"] != `[CRITICAL] Aider: rm -rf "/"` || rc.headers.Get("Authorization") != "Bearer test" {
+		t.Fatalf("unexpected request: %v %v", doc, rc.headers)
+	}
+}
+
+func TestWebhookFiltersAndDedupsWithinCooldown(t *testing.T) {
+	rc, srv := newReceiver(t, 0)
+	w := NewWebhooks([]appconfig.Webhook{{
+		Name:        "pager",
+		URL:         srv.URL,
+		MinLevel:    "critical",
+		MinSeverity: "high",
+		Agents:      []string{"claude-code"},
+	}}, time.Hour)
+
+	now := time.Now()
+	w.Publish(collector.State{
+		Alerts: []agent.Alert{
+			{Timestamp: now, Level: agent.AlertWarning, AgentID: "claude-code", Message: "cpu"},
+			{Timestamp: now, Level: agent.AlertCritical, AgentID: "claude-code", Message: "cost"},
+		},
+		SecurityEvents: []agent.SecurityEvent{
+			{Timestamp: now, Severity: agent.SecSevCritical, AgentID: "aider", Detail: "sudo"},
+			{Timestamp: now, Severity: agent.SecSevMedium, AgentID: "claude-code", Detail: "crontab"},
+		},
+	})
+	// The monitor raises the same alert again later: within cooldown
+	w.Publish(collector.State{Alerts: []agent.Alert{
+		{Timestamp: now.Add(time.Second), Level: agent.AlertCritical, AgentID: "claude-code", Message: "cost"},
+	}})
+	w.Close()
+
+	if len(rc.bodies) != 1 {
+		t.Fatalf("expected only the critical cost alert once, got %v", rc.bodies)
+	}
+	var n Notification
+	if err := json.Unmarshal([]byte(rc.bodies[0]), &n); err != nil || n.Message != "cost" || n.Kind != KindAlert {
+		t.Fatalf("unexpected default body %q: %v", rc.bodies[0], err)
+	}
+}
+
+func TestWebhookSlowTargetDoesNotHoldUpOthers(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	t.Cleanup(slow.Close)
+	t.Cleanup(func() { close(release) })
+
+	delivered := make(chan struct{}, 1)
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delivered <- struct{}{}
+	}))
+	t.Cleanup(fast.Close)
+
+	w := NewWebhooks([]appconfig.Webhook{
+		{Name: "slow", URL: slow.URL},
+		{Name: "fast", URL: fast.URL},
+	}, time.Minute)
+	w.Publish(collector.State{Alerts: []agent.Alert{{Timestamp: time.Now(), Level: agent.AlertCritical, Message: "cost"}}})
+
+	select {
+	case <-delivered:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("expected the fast target to be delivered while the slow one hangs")
+	}
+}
+
+func TestWebhookErrorClearsAfterSuccessfulDelivery(t *testing.T) {
+	rc, srv := newReceiver(t, 1)
+	w := NewWebhooks([]appconfig.Webhook{{Name: "chat", URL: srv.URL}}, time.Minute)
+	defer w.Close()
+
+	attempts := func(n int) {
+		t.Helper()
+		for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+			rc.mu.Lock()
+			done := rc.attempts >= n
+			rc.mu.Unlock()
+			if done {
+				// The worker records the outcome right after the response
+				time.Sleep(20 * time.Millisecond)
+				return
+			}
+		}
+		t.Fatalf("expected %d delivery attempts", n)
+	}
+
+	at := time.Now()
+	w.Publish(collector.State{SecurityEvents: []agent.SecurityEvent{{
+		Timestamp: at, Severity: agent.SecSevCritical, AgentName: "Aider", Description: "first",
+	}}})
+	attempts(1)
+	if w.LastError() == nil {
+		t.Fatal("expected the failed delivery to be reported")
+	}
+
+	w.Publish(collector.State{SecurityEvents: []agent.SecurityEvent{{
+		Timestamp: at.Add(time.Second), Severity: agent.SecSevCritical, AgentName: "Aider", Description: "second",
+	}}})
+	attempts(2)
+	if err := w.LastError(); err != nil {
+		t.Fatalf("expected the error to clear after a delivery, got %v", err)
+	}
+}
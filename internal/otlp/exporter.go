@@ -0,0 +1,176 @@
+package otlp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+	"github.com/rafaelperezbeato/agentmetrics/internal/appconfig"
+	"github.com/rafaelperezbeato/agentmetrics/internal/collector"
+)
+
+const (
+	metricsPath = "/v1/metrics"
+	logsPath    = "/v1/logs"
+	queueSize   = 32
+)
+
+// batch is one pending OTLP/HTTP request
+type batch struct {
+	path string
+	body []byte
+}
+
+// Exporter pushes agent metrics and events to an OTLP/HTTP collector using
+// the JSON encoding. It implements collector.Sink; requests are sent from a
+// background worker so Publish never blocks the collection loop.
+type Exporter struct {
+	cfg    appconfig.OTLPConfig
+	client *http.Client
+
+	watermark   collector.Watermark
+	lastMetrics time.Time
+	// starts keeps each process's counter start time across exports
+	starts map[int]time.Time
+
+	queue chan batch
+	wg    sync.WaitGroup
+
+	mu      sync.Mutex
+	lastErr error
+}
+
+// NewExporter creates an exporter and starts its send worker
+func NewExporter(cfg appconfig.OTLPConfig) *Exporter {
+	timeout := cfg.Timeout.Duration()
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = "agentmetrics"
+	}
+
+	e := &Exporter{
+		cfg:    cfg,
+		client: &http.Client{Timeout: timeout},
+		queue:  make(chan batch, queueSize),
+		starts: map[int]time.Time{},
+	}
+	e.wg.Add(1)
+	go e.run()
+	return e
+}
+
+// Publish queues new alerts and security events as logs, and agent
+// metrics at most once per configured interval.
+func (e *Exporter) Publish(st collector.State) {
+	now := time.Now()
+
+	alerts, events := e.watermark.NewEvents(st)
+	if len(alerts) > 0 || len(events) > 0 {
+		e.enqueue(logsPath, buildLogs(e.cfg.ServiceName, st.Agents, alerts, events, now))
+	}
+
+	if now.Sub(e.lastMetrics) >= e.cfg.Interval.Duration() {
+		e.lastMetrics = now
+		if len(st.Agents) > 0 {
+			e.enqueue(metricsPath, buildMetrics(e.cfg.ServiceName, st.Agents, e.seriesStarts(st.Agents, now), now))
+		}
+	}
+}
+
+// seriesStarts returns each agent's counter start time: its session start
+// or, when that is unknown, when the exporter first saw the process. A
+// cumulative series must keep its start, or backends read every export as
+// a reset. Processes that are gone are forgotten.
+func (e *Exporter) seriesStarts(agents []agent.Instance, now time.Time) map[int]time.Time {
+	live := make(map[int]time.Time, len(agents))
+	for _, a := range agents {
+		start := a.Session.StartedAt
+		if start.IsZero() {
+			start = now
+			if seen, ok := e.starts[a.PID]; ok {
+				start = seen
+			}
+		}
+		live[a.PID] = start
+	}
+	e.starts = live
+	return live
+}
+
+// Close flushes queued requests and stops the worker
+func (e *Exporter) Close() {
+	close(e.queue)
+	e.wg.Wait()
+}
+
+// Name identifies the exporter in error reports
+func (e *Exporter) Name() string {
+	return "otlp"
+}
+
+// LastError returns the most recent delivery error, if any
+func (e *Exporter) LastError() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.lastErr
+}
+
+// enqueue drops the payload when the collector is too slow to keep up
+func (e *Exporter) enqueue(path string, payload any) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		e.setErr(fmt.Errorf("encoding %s: %w", path, err))
+		return
+	}
+	select {
+	case e.queue <- batch{path: path, body: body}:
+	default:
+		e.setErr(fmt.Errorf("otlp queue full, dropped %s payload", path))
+	}
+}
+
+func (e *Exporter) run() {
+	defer e.wg.Done()
+	for b := range e.queue {
+		e.setErr(e.send(b))
+	}
+}
+
+// send posts one batch to the collector
+func (e *Exporter) send(b batch) error {
+	url := strings.TrimRight(e.cfg.Endpoint, "/") + b.path
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(b.body))
+	if err != nil {
+		return fmt.Errorf("building request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("posting %s: %w", b.path, err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("posting %s: collector returned %s", b.path, resp.Status)
+	}
+	return nil
+}
+
+func (e *Exporter) setErr(err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.lastErr = err
+}
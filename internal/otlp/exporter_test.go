@@ -0,0 +1,183 @@
+package otlp
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+	"github.com/rafaelperezbeato/agentmetrics/internal/appconfig"
+	"github.com/rafaelperezbeato/agentmetrics/internal/collector"
+)
+
+// fakeCollector is a stand-in OTLP/HTTP receiver
+type fakeCollector struct {
+	mu       sync.Mutex
+	requests map[string][]map[string]any
+	headers  http.Header
+}
+
+func newFakeCollector(t *testing.T) (*fakeCollector, *httptest.Server) {
+	t.Helper()
+	fc := &fakeCollector{requests: map[string][]map[string]any{}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var doc map[string]any
+		if err := json.Unmarshal(body, &doc); err != nil {
+			t.Errorf("collector received invalid JSON: %v", err)
+		}
+		fc.mu.Lock()
+		fc.requests[r.URL.Path] = append(fc.requests[r.URL.Path], doc)
+		fc.headers = r.Header.Clone()
+		fc.mu.Unlock()
+	}))
+	t.Cleanup(srv.Close)
+	return fc, srv
+}
+
+func testConfig(endpoint string) appconfig.OTLPConfig {
+	return appconfig.OTLPConfig{
+		Enabled:     true,
+		Endpoint:    endpoint,
+		Headers:     map[string]string{"Authorization": "Bearer test"},
+		Interval:    appconfig.Duration(time.Hour),
+		ServiceName: "agentmetrics-test",
+	}
+}
+
+func TestExporterPushesMetricsWithAgentResource(t *testing.T) {
+	fc, srv := newFakeCollector(t)
+	exp := NewExporter(testConfig(srv.URL))
+
+	st := collector.State{Agents: []agent.Instance{{
+		PID:     4242,
+		Info:    agent.Info{ID: "claude-code", Name: "Claude Code"},
+		WorkDir: "/src/repo",
+		CPU:     3.5,
+	}}}
+	exp.Publish(st)
+	exp.Publish(st) // within interval: no second metrics push
+	exp.Close()
+
+	if err := exp.LastError(); err != nil {
+		t.Fatalf("unexpected export error: %v", err)
+	}
+	if got := len(fc.requests[metricsPath]); got != 1 {
+		t.Fatalf("expected 1 metrics request, got %d", got)
+	}
+	if fc.headers.Get("Authorization") != "Bearer test" {
+		t.Fatalf("expected configured headers to be sent")
+	}
+
+	rm := fc.requests[metricsPath][0]["resourceMetrics"].([]any)[0].(map[string]any)
+	attrs := rm["resource"].(map[string]any)["attributes"].([]any)
+	found := map[string]bool{}
+	for _, raw := range attrs {
+		found[raw.(map[string]any)["key"].(string)] = true
+	}
+	for _, key := range []string{"service.name", "agent.name", "process.pid", "process.working_directory"} {
+		if !found[key] {
+			t.Fatalf("expected resource attribute %q, got %v", key, attrs)
+		}
+	}
+}
+
+func TestExporterSendsEachEventOnceAsLog(t *testing.T) {
+	fc, srv := newFakeCollector(t)
+	exp := NewExporter(testConfig(srv.URL))
+
+	now := time.Now()
+	st := collector.State{
+		Alerts: []agent.Alert{{Timestamp: now, Level: agent.AlertCritical, AgentID: "aider", Message: "cost"}},
+		SecurityEvents: []agent.SecurityEvent{
+			{Timestamp: now, Severity: agent.SecSevCritical, AgentID: "aider", Description: "reverse shell"},
+		},
+	}
+	exp.Publish(st)
+	exp.Publish(st)
+	exp.Close()
+
+	if got := len(fc.requests[logsPath]); got != 1 {
+		t.Fatalf("expected 1 logs request, got %d", got)
+	}
+
+	rl := fc.requests[logsPath][0]["resourceLogs"].([]any)[0].(map[string]any)
+	records := rl["scopeLogs"].([]any)[0].(map[string]any)["logRecords"].([]any)
+	if len(records) != 2 {
+		t.Fatalf("expected 2 log records, got %d", len(records))
+	}
+	sev := records[1].(map[string]any)["severityNumber"].(float64)
+	if int(sev) != severityFatal {
+		t.Fatalf("expected critical security event to map to FATAL, got %v", sev)
+	}
+}
+
+func TestExporterRecordsCollectorErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	exp := NewExporter(testConfig(srv.URL))
+	exp.Publish(collector.State{Agents: []agent.Instance{{PID: 1}}})
+	exp.Close()
+
+	if exp.LastError() == nil {
+		t.Fatalf("expected delivery error to be recorded")
+	}
+}
+
+func TestBuildLogsAttributesEventsToTheirProcess(t *testing.T) {
+	first := agent.Instance{PID: 1, Info: agent.Info{ID: "claude-code"}, WorkDir: "/src/web"}
+	second := agent.Instance{PID: 2, Info: agent.Info{ID: "claude-code"}, WorkDir: "/src/api"}
+	second.Terminal.RecentCommands = []agent.TerminalCommand{{Command: "rm -rf build"}}
+	now := time.Now()
+
+	req := buildLogs("svc", []agent.Instance{first, second}, []agent.Alert{{AgentID: "claude-code", Message: "High CPU"}},
+		[]agent.SecurityEvent{{AgentID: "claude-code", Detail: "rm -rf build", Description: "Dangerous"}}, now)
+	if len(req.ResourceLogs) != 2 {
+		t.Fatalf("expected an agent-level and a process resource, got %+v", req.ResourceLogs)
+	}
+
+	pidOf := func(res resource) string {
+		for _, kv := range res.Attributes {
+			if kv.Key == "process.pid" {
+				return *kv.Value.IntValue
+			}
+		}
+		return ""
+	}
+	// Either instance could have raised the alert
+	if pid := pidOf(req.ResourceLogs[0].Resource); pid != "" {
+		t.Fatalf("the alert can't be traced to one process, got PID %s", pid)
+	}
+	if pid := pidOf(req.ResourceLogs[1].Resource); pid != "2" {
+		t.Fatalf("expected the event under the second session, got PID %q", pid)
+	}
+}
+
+func TestSeriesStartsStayStable(t *testing.T) {
+	exp := &Exporter{starts: map[int]time.Time{}}
+	started := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	known := agent.Instance{PID: 1}
+	known.Session.StartedAt = started
+	unknown := agent.Instance{PID: 2}
+
+	first := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	exp.seriesStarts([]agent.Instance{known, unknown}, first)
+	starts := exp.seriesStarts([]agent.Instance{known, unknown}, first.Add(time.Minute))
+	if !starts[1].Equal(started) || !starts[2].Equal(first) {
+		t.Fatalf("expected the session start and the first export, got %v", starts)
+	}
+
+	// A PID that exits and comes back is a new series
+	exp.seriesStarts([]agent.Instance{known}, first.Add(2*time.Minute))
+	starts = exp.seriesStarts([]agent.Instance{known, unknown}, first.Add(3*time.Minute))
+	if !starts[2].Equal(first.Add(3 * time.Minute)) {
+		t.Fatalf("expected a restarted series, got %v", starts[2])
+	}
+}
@@ -0,0 +1,342 @@
+package otlp
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+	"github.com/Rafiki81/libagentmetrics/monitor"
+)
+
+// The types below mirror the OTLP/HTTP JSON encoding of
+// ExportMetricsServiceRequest and ExportLogsServiceRequest. Only the
+// fields agentmetrics emits are modelled.
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+type anyValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+	BoolValue   *bool   `json:"boolValue,omitempty"`
+}
+
+type resource struct {
+	Attributes []keyValue `json:"attributes"`
+}
+
+type scope struct {
+	Name string `json:"name"`
+}
+
+type metricsRequest struct {
+	ResourceMetrics []resourceMetrics `json:"resourceMetrics"`
+}
+
+type resourceMetrics struct {
+	Resource     resource       `json:"resource"`
+	ScopeMetrics []scopeMetrics `json:"scopeMetrics"`
+}
+
+type scopeMetrics struct {
+	Scope   scope    `json:"scope"`
+	Metrics []metric `json:"metrics"`
+}
+
+type metric struct {
+	Name  string `json:"name"`
+	Unit  string `json:"unit,omitempty"`
+	Gauge *gauge `json:"gauge,omitempty"`
+	Sum   *sum   `json:"sum,omitempty"`
+}
+
+type gauge struct {
+	DataPoints []dataPoint `json:"dataPoints"`
+}
+
+// aggregationCumulative is AGGREGATION_TEMPORALITY_CUMULATIVE
+const aggregationCumulative = 2
+
+type sum struct {
+	DataPoints             []dataPoint `json:"dataPoints"`
+	AggregationTemporality int         `json:"aggregationTemporality"`
+	IsMonotonic            bool        `json:"isMonotonic"`
+}
+
+type dataPoint struct {
+	StartTimeUnixNano string   `json:"startTimeUnixNano,omitempty"`
+	TimeUnixNano      string   `json:"timeUnixNano"`
+	AsDouble          *float64 `json:"asDouble,omitempty"`
+	AsInt             *string  `json:"asInt,omitempty"`
+}
+
+type logsRequest struct {
+	ResourceLogs []resourceLogs `json:"resourceLogs"`
+}
+
+type resourceLogs struct {
+	Resource  resource    `json:"resource"`
+	ScopeLogs []scopeLogs `json:"scopeLogs"`
+}
+
+type scopeLogs struct {
+	Scope      scope       `json:"scope"`
+	LogRecords []logRecord `json:"logRecords"`
+}
+
+type logRecord struct {
+	TimeUnixNano         string     `json:"timeUnixNano"`
+	ObservedTimeUnixNano string     `json:"observedTimeUnixNano"`
+	SeverityNumber       int        `json:"severityNumber"`
+	SeverityText         string     `json:"severityText"`
+	Body                 anyValue   `json:"body"`
+	Attributes           []keyValue `json:"attributes"`
+}
+
+// OTLP SeverityNumber values for the levels agentmetrics uses
+const (
+	severityInfo  = 9
+	severityWarn  = 13
+	severityError = 17
+	severityFatal = 21
+)
+
+const scopeName = "github.com/rafaelperezbeato/agentmetrics"
+
+func stringAttr(key, value string) keyValue {
+	return keyValue{Key: key, Value: anyValue{StringValue: &value}}
+}
+
+func intAttr(key string, value int64) keyValue {
+	s := strconv.FormatInt(value, 10)
+	return keyValue{Key: key, Value: anyValue{IntValue: &s}}
+}
+
+func boolAttr(key string, value bool) keyValue {
+	return keyValue{Key: key, Value: anyValue{BoolValue: &value}}
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func gaugeMetric(name, unit string, value float64, now time.Time) metric {
+	return metric{
+		Name: name,
+		Unit: unit,
+		Gauge: &gauge{DataPoints: []dataPoint{{
+			TimeUnixNano: unixNano(now),
+			AsDouble:     &value,
+		}}},
+	}
+}
+
+func counterMetric(name, unit string, value int64, start, now time.Time) metric {
+	v := strconv.FormatInt(value, 10)
+	return metric{
+		Name: name,
+		Unit: unit,
+		Sum: &sum{
+			DataPoints: []dataPoint{{
+				StartTimeUnixNano: unixNano(start),
+				TimeUnixNano:      unixNano(now),
+				AsInt:             &v,
+			}},
+			AggregationTemporality: aggregationCumulative,
+			IsMonotonic:            true,
+		},
+	}
+}
+
+// agentResource describes one agent process as an OTLP resource
+func agentResource(service string, a agent.Instance) resource {
+	return resource{Attributes: []keyValue{
+		stringAttr("service.name", service),
+		stringAttr("agent.id", a.Info.ID),
+		stringAttr("agent.name", a.Info.Name),
+		intAttr("process.pid", int64(a.PID)),
+		stringAttr("process.working_directory", a.WorkDir),
+	}}
+}
+
+// buildMetrics converts agents into one resource per agent process.
+// starts holds each process's counter start time.
+func buildMetrics(service string, agents []agent.Instance, starts map[int]time.Time, now time.Time) metricsRequest {
+	req := metricsRequest{ResourceMetrics: []resourceMetrics{}}
+	for _, a := range agents {
+		start, ok := starts[a.PID]
+		if !ok {
+			start = now
+		}
+		req.ResourceMetrics = append(req.ResourceMetrics, resourceMetrics{
+			Resource: agentResource(service, a),
+			ScopeMetrics: []scopeMetrics{{
+				Scope: scope{Name: scopeName},
+				Metrics: []metric{
+					gaugeMetric("agent.cpu.utilization", "%", a.CPU, now),
+					gaugeMetric("agent.memory.usage", "MBy", a.Memory, now),
+					gaugeMetric("agent.cost", "USD", a.Tokens.EstCost, now),
+					gaugeMetric("agent.uptime", "s", a.Session.Uptime.Seconds(), now),
+					counterMetric("agent.tokens", "{token}", a.Tokens.TotalTokens, start, now),
+					counterMetric("agent.requests", "{request}", int64(a.Tokens.RequestCount), start, now),
+				},
+			}},
+		})
+	}
+	return req
+}
+
+// ownerOf finds the process an event came from: the only running instance
+// of its agent, or the one whose commands, file operations or connections
+// include detail. Events carry no PID, so when several instances could
+// have produced it, it reports false rather than pick one.
+func ownerOf(agents []agent.Instance, agentID, detail string) (agent.Instance, bool) {
+	var candidates []agent.Instance
+	for _, a := range agents {
+		if a.Info.ID == agentID {
+			candidates = append(candidates, a)
+		}
+	}
+	if len(candidates) == 1 {
+		return candidates[0], true
+	}
+	if detail == "" {
+		return agent.Instance{}, false
+	}
+
+	var owner agent.Instance
+	found := 0
+	for _, a := range candidates {
+		if mentions(a, detail) {
+			owner = a
+			found++
+		}
+	}
+	return owner, found == 1
+}
+
+// mentions reports whether detail is one of a's recent commands, file
+// paths or connections
+func mentions(a agent.Instance, detail string) bool {
+	for _, cmd := range a.Terminal.RecentCommands {
+		if cmd.Command == detail {
+			return true
+		}
+	}
+	for _, op := range a.FileOps {
+		if op.Path == detail {
+			return true
+		}
+	}
+	for _, conn := range a.NetConns {
+		if monitor.DescribeConnection(conn) == detail {
+			return true
+		}
+	}
+	return false
+}
+
+// buildLogs turns alerts and security events into log records, grouped
+// under the resource of the process that produced them. Records that
+// can't be traced to one process share a resource naming only the agent.
+func buildLogs(service string, agents []agent.Instance, alerts []agent.Alert, events []agent.SecurityEvent, now time.Time) logsRequest {
+	byPID := map[int]*resourceLogs{}
+	byAgent := map[string]*resourceLogs{}
+	var order []*resourceLogs
+
+	resourceFor := func(agentID, detail string) *resourceLogs {
+		a, ok := ownerOf(agents, agentID, detail)
+		if ok {
+			if rl, seen := byPID[a.PID]; seen {
+				return rl
+			}
+		} else if rl, seen := byAgent[agentID]; seen {
+			return rl
+		}
+
+		res := resource{Attributes: []keyValue{
+			stringAttr("service.name", service),
+			stringAttr("agent.id", agentID),
+		}}
+		if ok {
+			res = agentResource(service, a)
+		}
+		rl := &resourceLogs{
+			Resource:  res,
+			ScopeLogs: []scopeLogs{{Scope: scope{Name: scopeName}}},
+		}
+		if ok {
+			byPID[a.PID] = rl
+		} else {
+			byAgent[agentID] = rl
+		}
+		order = append(order, rl)
+		return rl
+	}
+
+	for _, al := range alerts {
+		msg := al.Message
+		rl := resourceFor(al.AgentID, "")
+		rl.ScopeLogs[0].LogRecords = append(rl.ScopeLogs[0].LogRecords, logRecord{
+			TimeUnixNano:         unixNano(al.Timestamp),
+			ObservedTimeUnixNano: unixNano(now),
+			SeverityNumber:       alertSeverity(al.Level),
+			SeverityText:         string(al.Level),
+			Body:                 anyValue{StringValue: &msg},
+			Attributes: []keyValue{
+				stringAttr("event.name", "agentmetrics.alert"),
+				stringAttr("agent.name", al.AgentName),
+			},
+		})
+	}
+
+	for _, evt := range events {
+		desc := evt.Description
+		rl := resourceFor(evt.AgentID, evt.Detail)
+		rl.ScopeLogs[0].LogRecords = append(rl.ScopeLogs[0].LogRecords, logRecord{
+			TimeUnixNano:         unixNano(evt.Timestamp),
+			ObservedTimeUnixNano: unixNano(now),
+			SeverityNumber:       securitySeverity(evt.Severity),
+			SeverityText:         string(evt.Severity),
+			Body:                 anyValue{StringValue: &desc},
+			Attributes: []keyValue{
+				stringAttr("event.name", "agentmetrics.security"),
+				stringAttr("security.category", string(evt.Category)),
+				stringAttr("security.detail", evt.Detail),
+				boolAttr("security.blocked", evt.Blocked),
+			},
+		})
+	}
+
+	req := logsRequest{ResourceLogs: []resourceLogs{}}
+	for _, rl := range order {
+		req.ResourceLogs = append(req.ResourceLogs, *rl)
+	}
+	return req
+}
+
+func alertSeverity(level agent.AlertLevel) int {
+	switch level {
+	case agent.AlertCritical:
+		return severityError
+	case agent.AlertWarning:
+		return severityWarn
+	default:
+		return severityInfo
+	}
+}
+
+func securitySeverity(sev agent.SecuritySeverity) int {
+	switch sev {
+	case agent.SecSevCritical:
+		return severityFatal
+	case agent.SecSevHigh:
+		return severityError
+	case agent.SecSevMedium:
+		return severityWarn
+	default:
+		return severityInfo
+	}
+}
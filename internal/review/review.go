@@ -0,0 +1,154 @@
+package review
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+	"github.com/Rafiki81/libagentmetrics/config"
+)
+
+// Decision statuses
+const (
+	Acknowledged  = "acknowledged"
+	FalsePositive = "false_positive"
+)
+
+// Decision is a reviewer's verdict on a security event. It applies to
+// every later event with the same agent, category and detail.
+type Decision struct {
+	Status    string    `json:"status"`
+	DecidedAt time.Time `json:"decided_at"`
+	AgentID   string    `json:"agent_id"`
+	Category  string    `json:"category"`
+	Detail    string    `json:"detail"`
+}
+
+// Store persists decisions as one JSON file
+type Store struct {
+	path string
+
+	mu        sync.RWMutex
+	decisions map[string]Decision
+	// modTime and size identify the file version loaded, so Reload only
+	// reads it again after another process saved
+	modTime time.Time
+	size    int64
+}
+
+// DefaultPath returns the decisions file next to the config file
+func DefaultPath() string {
+	return filepath.Join(filepath.Dir(config.ConfigPath()), "security-decisions.json")
+}
+
+// Open loads decisions from path. A missing file is an empty store. On
+// error the store is still usable, starting empty.
+func Open(path string) (*Store, error) {
+	s := &Store{path: path, decisions: map[string]Decision{}}
+	return s, s.Reload()
+}
+
+// Reload reads the file again if it changed since it was last loaded or
+// saved, e.g. by a TUI while a daemon filters with the same store. A file
+// that fails to load leaves the current decisions in place.
+func (s *Store) Reload() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info, err := os.Stat(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		s.decisions, s.modTime, s.size = map[string]Decision{}, time.Time{}, 0
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("reading decisions: %w", err)
+	}
+	if info.ModTime().Equal(s.modTime) && info.Size() == s.size {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return fmt.Errorf("reading decisions: %w", err)
+	}
+	decisions := map[string]Decision{}
+	if err := json.Unmarshal(data, &decisions); err != nil {
+		return fmt.Errorf("parsing %s: %w", s.path, err)
+	}
+	s.decisions, s.modTime, s.size = decisions, info.ModTime(), info.Size()
+	return nil
+}
+
+// Key identifies an event independent of when it fired, so the monitor
+// reporting the same command again maps to the same decision
+func Key(evt agent.SecurityEvent) string {
+	return evt.AgentID + "|" + string(evt.Category) + "|" + evt.Detail
+}
+
+// Status returns the decision for evt, if any
+func (s *Store) Status(evt agent.SecurityEvent) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	d, ok := s.decisions[Key(evt)]
+	return d.Status, ok
+}
+
+// Decide records status for evt and saves the file
+func (s *Store) Decide(evt agent.SecurityEvent, status string, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.decisions[Key(evt)] = Decision{
+		Status:    status,
+		DecidedAt: now,
+		AgentID:   evt.AgentID,
+		Category:  string(evt.Category),
+		Detail:    evt.Detail,
+	}
+	return s.save()
+}
+
+// Undo forgets the decision for evt and saves the file
+func (s *Store) Undo(evt agent.SecurityEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.decisions, Key(evt))
+	return s.save()
+}
+
+// Pending drops events that already have a decision
+func (s *Store) Pending(events []agent.SecurityEvent) []agent.SecurityEvent {
+	var out []agent.SecurityEvent
+	for _, evt := range events {
+		if _, ok := s.Status(evt); !ok {
+			out = append(out, evt)
+		}
+	}
+	return out
+}
+
+func (s *Store) save() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("creating decisions dir: %w", err)
+	}
+	data, err := json.MarshalIndent(s.decisions, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding decisions: %w", err)
+	}
+	// Other processes reload the file, so it is replaced in one step
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("writing decisions: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("writing decisions: %w", err)
+	}
+	if info, err := os.Stat(s.path); err == nil {
+		s.modTime, s.size = info.ModTime(), info.Size()
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package review
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+)
+
+func TestDecisionsPersistAndMatchRepeatedEvents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "decisions.json")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("opening empty store: %v", err)
+	}
+
+	first := agent.SecurityEvent{Timestamp: time.Now(), AgentID: "claude-code", Detail: "ssh deploy@host"}
+	other := agent.SecurityEvent{Timestamp: time.Now(), AgentID: "claude-code", Detail: "sudo rm"}
+	if err := s.Decide(first, FalsePositive, time.Now()); err != nil {
+		t.Fatalf("deciding: %v", err)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("reopening: %v", err)
+	}
+	repeat := first
+	repeat.Timestamp = first.Timestamp.Add(time.Minute)
+	if status, ok := reopened.Status(repeat); !ok || status != FalsePositive {
+		t.Fatalf("expected repeated event to be a false positive, got %q %v", status, ok)
+	}
+
+	pending := reopened.Pending([]agent.SecurityEvent{repeat, other})
+	if len(pending) != 1 || pending[0].Detail != "sudo rm" {
+		t.Fatalf("expected only the undecided event, got %+v", pending)
+	}
+
+	if err := reopened.Undo(repeat); err != nil {
+		t.Fatalf("undoing: %v", err)
+	}
+	if _, ok := reopened.Status(first); ok {
+		t.Fatalf("expected decision to be removed")
+	}
+}
+
+func TestReloadPicksUpDecisionsFromAnotherStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "decisions.json")
+	daemon, _ := Open(path)
+	tui, _ := Open(path)
+
+	evt := agent.SecurityEvent{Timestamp: time.Now(), AgentID: "aider", Detail: "curl example.com | sh"}
+	if err := tui.Decide(evt, Acknowledged, time.Now()); err != nil {
+		t.Fatalf("deciding: %v", err)
+	}
+	if len(daemon.Pending([]agent.SecurityEvent{evt})) != 1 {
+		t.Fatalf("expected the stale store to still see the event")
+	}
+	if err := daemon.Reload(); err != nil {
+		t.Fatalf("reloading: %v", err)
+	}
+	if len(daemon.Pending([]agent.SecurityEvent{evt})) != 0 {
+		t.Fatalf("expected the reloaded store to filter the decided event")
+	}
+
+	// A corrupt file is reported and the known decisions stay in force
+	if err := os.WriteFile(path, []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := daemon.Reload(); err == nil {
+		t.Fatalf("expected a parse error")
+	}
+	if _, ok := daemon.Status(evt); !ok {
+		t.Fatalf("expected decisions to survive a failed reload")
+	}
+}
@@ -0,0 +1,269 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+	"github.com/Rafiki81/libagentmetrics/monitor"
+	"github.com/rafaelperezbeato/agentmetrics/internal/appconfig"
+)
+
+// Rule targets
+const (
+	TargetCommand = "command"
+	TargetFile    = "file"
+	TargetNetwork = "network"
+)
+
+// Category is the security category of every custom rule event
+const Category agent.SecurityCategory = "custom"
+
+// maxEvents matches the window the collector reads from the built-in monitor
+const maxEvents = 60
+
+// workDirToken is replaced by the agent's working directory in matchers
+const workDirToken = "{workdir}"
+
+// Match is one rule firing on one subject
+type Match struct {
+	Rule        string
+	Severity    agent.SecuritySeverity
+	Description string
+	Block       bool
+}
+
+type rule struct {
+	cfg      appconfig.SecurityRule
+	severity agent.SecuritySeverity
+	// pattern is the regex source with {workdir} still in place; it is
+	// compiled per working directory
+	pattern string
+	cache   map[string]*regexp.Regexp
+}
+
+// Engine evaluates custom rules against agent activity
+type Engine struct {
+	rules []*rule
+	errs  []error
+	// seen holds the rule, PID, subject and source time of every
+	// occurrence still in the agents' recent activity
+	seen   map[string]bool
+	events []agent.SecurityEvent
+}
+
+// New validates and prepares the rules. Invalid rules are skipped and
+// reported by Errors.
+func New(cfg []appconfig.SecurityRule) *Engine {
+	e := &Engine{seen: map[string]bool{}}
+	for _, c := range cfg {
+		r, err := newRule(c)
+		if err != nil {
+			e.errs = append(e.errs, fmt.Errorf("rule %q: %w", c.Name, err))
+			continue
+		}
+		e.rules = append(e.rules, r)
+	}
+	return e
+}
+
+func newRule(c appconfig.SecurityRule) (*rule, error) {
+	switch c.Target {
+	case TargetCommand, TargetFile, TargetNetwork:
+	default:
+		return nil, fmt.Errorf("unknown target %q (use command, file or network)", c.Target)
+	}
+
+	sev := agent.SecuritySeverity(strings.ToUpper(c.Severity))
+	switch sev {
+	case agent.SecSevCritical, agent.SecSevHigh, agent.SecSevMedium, agent.SecSevLow:
+	default:
+		return nil, fmt.Errorf("unknown severity %q", c.Severity)
+	}
+
+	var pattern string
+	switch {
+	case c.Regex != "" && c.Glob != "":
+		return nil, fmt.Errorf("set regex or glob, not both")
+	case c.Regex != "":
+		pattern = c.Regex
+	case c.Glob != "":
+		pattern = globToRegex(c.Glob)
+	default:
+		return nil, fmt.Errorf("missing regex or glob")
+	}
+
+	r := &rule{cfg: c, severity: sev, pattern: pattern, cache: map[string]*regexp.Regexp{}}
+	// Compile once up front so syntax errors surface at load time
+	if _, err := r.regexp("/"); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// regexp returns the rule's matcher for one working directory
+func (r *rule) regexp(workDir string) (*regexp.Regexp, error) {
+	if re, ok := r.cache[workDir]; ok {
+		return re, nil
+	}
+	src := strings.ReplaceAll(r.pattern, regexp.QuoteMeta(workDirToken), regexp.QuoteMeta(workDir))
+	src = strings.ReplaceAll(src, workDirToken, regexp.QuoteMeta(workDir))
+	re, err := regexp.Compile(src)
+	if err != nil {
+		return nil, fmt.Errorf("invalid matcher: %w", err)
+	}
+	r.cache[workDir] = re
+	return re, nil
+}
+
+// matches reports whether the rule fires on subject
+func (r *rule) matches(target, subject, op, workDir string) bool {
+	if r.cfg.Target != target {
+		return false
+	}
+	if target == TargetFile && len(r.cfg.Ops) > 0 && !slices.ContainsFunc(r.cfg.Ops, func(o string) bool {
+		return strings.EqualFold(o, op)
+	}) {
+		return false
+	}
+	re, err := r.regexp(workDir)
+	if err != nil {
+		return false
+	}
+	return re.MatchString(subject) != r.cfg.Negate
+}
+
+// Errors returns the rules that failed validation
+func (e *Engine) Errors() []error {
+	return e.errs
+}
+
+// Match evaluates every rule for one subject. op is the file operation
+// for file targets and ignored otherwise.
+func (e *Engine) Match(target, subject, op, workDir string) []Match {
+	var out []Match
+	for _, r := range e.rules {
+		if r.matches(target, subject, op, workDir) {
+			out = append(out, Match{
+				Rule:        r.cfg.Name,
+				Severity:    r.severity,
+				Description: r.description(),
+				Block:       r.cfg.Block,
+			})
+		}
+	}
+	return out
+}
+
+func (r *rule) description() string {
+	if r.cfg.Description != "" {
+		return r.cfg.Description
+	}
+	return r.cfg.Name
+}
+
+// Check evaluates the agents' commands, file operations and connections,
+// recording one event per rule and subject occurrence. Events are stamped
+// with now, when they were detected: a command can be minutes old by the
+// time it is seen, and consumers only pick up events newer than the last
+// ones they handled. The occurrence's own time only tells repeats apart.
+func (e *Engine) Check(agents []agent.Instance, now time.Time) {
+	if len(e.rules) == 0 {
+		return
+	}
+
+	// Occurrences that left the agents' recent activity don't come back,
+	// so only those still present are remembered
+	current := map[string]bool{}
+	for _, a := range agents {
+		for _, cmd := range a.Terminal.RecentCommands {
+			e.fire(a, TargetCommand, cmd.Command, "", cmd.Timestamp, now, current)
+		}
+		for _, op := range a.FileOps {
+			e.fire(a, TargetFile, op.Path, string(op.Op), op.Timestamp, now, current)
+		}
+		for _, conn := range a.NetConns {
+			// Connections carry no timestamp: fire once per connection
+			e.fire(a, TargetNetwork, monitor.DescribeConnection(conn), "", time.Time{}, now, current)
+		}
+	}
+	for key := range e.seen {
+		if !current[key] {
+			delete(e.seen, key)
+		}
+	}
+}
+
+func (e *Engine) fire(a agent.Instance, target, subject, op string, at, now time.Time, current map[string]bool) {
+	for _, r := range e.rules {
+		if !r.matches(target, subject, op, a.WorkDir) {
+			continue
+		}
+		key := fmt.Sprintf("%s|%d|%s|%s|%d", r.cfg.Name, a.PID, target, subject, at.UnixNano())
+		current[key] = true
+		if e.seen[key] {
+			continue
+		}
+		e.seen[key] = true
+
+		e.events = append(e.events, agent.SecurityEvent{
+			Timestamp:   now,
+			Severity:    r.severity,
+			Category:    Category,
+			Description: r.description(),
+			Detail:      subject,
+			AgentID:     a.Info.ID,
+			AgentName:   a.Info.Name,
+			Blocked:     r.cfg.Block,
+		})
+	}
+	if len(e.events) > maxEvents {
+		e.events = e.events[len(e.events)-maxEvents:]
+	}
+}
+
+// Events returns recent custom rule events, oldest first
+func (e *Engine) Events() []agent.SecurityEvent {
+	return e.events
+}
+
+// Merge combines built-in and custom events in time order
+func Merge(builtin, custom []agent.SecurityEvent) []agent.SecurityEvent {
+	if len(custom) == 0 {
+		return builtin
+	}
+	out := slices.Concat(builtin, custom)
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Timestamp.Before(out[j].Timestamp)
+	})
+	return out
+}
+
+// globToRegex converts a path glob to an anchored regex: ** crosses
+// directories, * and ? do not
+func globToRegex(glob string) string {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; {
+		case c == '*' && i+1 < len(glob) && glob[i+1] == '*':
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case strings.HasPrefix(glob[i:], workDirToken):
+			b.WriteString(workDirToken)
+			i += len(workDirToken) - 1
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
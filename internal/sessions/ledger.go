@@ -0,0 +1,82 @@
+package sessions
+
+import (
+	"time"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+)
+
+// DailyCost splits a session's cost by local calendar day, keyed
+// "2006-01-02"
+type DailyCost map[string]float64
+
+// Since sums the cost of the days from since's day on
+func (d DailyCost) Since(since time.Time) float64 {
+	from := since.Local().Format(time.DateOnly)
+	total := 0.0
+	for day, cost := range d {
+		if day >= from {
+			total += cost
+		}
+	}
+	return total
+}
+
+// Ledger attributes each agent's cost to the day it was spent. The
+// library only reports a session's running total, so the ledger keeps the
+// total it last saw per PID and books the difference on every scan.
+type Ledger struct {
+	last map[int]float64
+	days map[int]DailyCost
+}
+
+// NewLedger creates an empty ledger
+func NewLedger() *Ledger {
+	return &Ledger{last: map[int]float64{}, days: map[int]DailyCost{}}
+}
+
+// Observe books the cost each agent accrued since the previous scan to
+// now's day. What an agent spent before it was first seen goes to the day
+// its session started; when that is unknown it isn't booked at all, since
+// a long-running session would otherwise land on the current day.
+func (l *Ledger) Observe(agents []agent.Instance, now time.Time) {
+	for _, a := range agents {
+		cost := a.Tokens.EstCost
+		prev, seen := l.last[a.PID]
+		l.last[a.PID] = cost
+
+		day := now
+		if !seen {
+			if a.Session.StartedAt.IsZero() {
+				continue
+			}
+			day = a.Session.StartedAt
+		}
+		// A lower total means the counter restarted; book nothing
+		if delta := cost - prev; delta > 0 {
+			if l.days[a.PID] == nil {
+				l.days[a.PID] = DailyCost{}
+			}
+			l.days[a.PID][day.Local().Format(time.DateOnly)] += delta
+		}
+	}
+}
+
+// Days returns the cost booked for pid per day
+func (l *Ledger) Days(pid int) DailyCost {
+	return l.days[pid]
+}
+
+// Forget drops every PID not in agents
+func (l *Ledger) Forget(agents []agent.Instance) {
+	live := make(map[int]bool, len(agents))
+	for _, a := range agents {
+		live[a.PID] = true
+	}
+	for pid := range l.last {
+		if !live[pid] {
+			delete(l.last, pid)
+			delete(l.days, pid)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package sessions
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Filter selects reports; empty fields match everything
+type Filter struct {
+	// Agent matches the agent ID or name, case-insensitively
+	Agent string
+	// WorkDir matches any report whose directory contains it
+	WorkDir string
+	// Model matches any report whose model contains it
+	Model string
+	// Since and Until bound the session end time (Until is exclusive)
+	Since time.Time
+	Until time.Time
+}
+
+// Match reports whether r passes every set criterion
+func (f Filter) Match(r Report) bool {
+	if f.Agent != "" && !strings.EqualFold(r.AgentID, f.Agent) && !containsFold(r.AgentName, f.Agent) {
+		return false
+	}
+	if f.WorkDir != "" && !strings.Contains(r.WorkDir, f.WorkDir) {
+		return false
+	}
+	if f.Model != "" && !containsFold(r.Model, f.Model) {
+		return false
+	}
+	if !f.Since.IsZero() && r.EndedAt.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !r.EndedAt.Before(f.Until) {
+		return false
+	}
+	return true
+}
+
+// Apply returns the reports that match
+func (f Filter) Apply(reports []Report) []Report {
+	var out []Report
+	for _, r := range reports {
+		if f.Match(r) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// Period is an aggregation bucket size
+type Period string
+
+const (
+	PeriodDay  Period = "day"
+	PeriodWeek Period = "week"
+)
+
+// ParsePeriod validates a --group value
+func ParsePeriod(s string) (Period, error) {
+	switch Period(s) {
+	case PeriodDay, PeriodWeek:
+		return Period(s), nil
+	default:
+		return "", fmt.Errorf("unknown period: %s (use 'day' or 'week')", s)
+	}
+}
+
+// start truncates t to the beginning of its bucket in local time.
+// Weeks start on Monday.
+func (p Period) start(t time.Time) time.Time {
+	t = t.Local()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	if p == PeriodWeek {
+		offset := (int(day.Weekday()) + 6) % 7
+		day = day.AddDate(0, 0, -offset)
+	}
+	return day
+}
+
+// label renders a bucket start as 2026-02-16 or 2026-W08
+func (p Period) label(start time.Time) string {
+	if p == PeriodWeek {
+		year, week := start.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	}
+	return start.Format("2006-01-02")
+}
+
+// Bucket is the aggregate of every session ending in one period
+type Bucket struct {
+	Period   string    `json:"period"`
+	Start    time.Time `json:"start"`
+	Sessions int       `json:"sessions"`
+	// Live counts the sessions still running
+	Live        int     `json:"live"`
+	TotalTokens int64   `json:"total_tokens"`
+	Cost        float64 `json:"cost_usd"`
+	ActiveSecs  int64   `json:"active_secs"`
+	LOCAdded    int     `json:"loc_added"`
+	LOCRemoved  int     `json:"loc_removed"`
+}
+
+// Aggregate sums reports per period, oldest bucket first. Live reports
+// count in the period containing now.
+func Aggregate(reports []Report, p Period) []Bucket {
+	byStart := map[time.Time]*Bucket{}
+	for _, r := range reports {
+		start := p.start(r.EndedAt)
+		b, ok := byStart[start]
+		if !ok {
+			b = &Bucket{Period: p.label(start), Start: start}
+			byStart[start] = b
+		}
+		b.Sessions++
+		if r.Live {
+			b.Live++
+		}
+		b.TotalTokens += r.TotalTokens
+		b.Cost += r.Cost
+		b.ActiveSecs += r.ActiveSecs
+		b.LOCAdded += r.LOCAdded
+		b.LOCRemoved += r.LOCRemoved
+	}
+
+	buckets := make([]Bucket, 0, len(byStart))
+	for _, b := range byStart {
+		buckets = append(buckets, *b)
+	}
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].Start.Before(buckets[j].Start)
+	})
+	return buckets
+}
+
+// ParseSince accepts a date (2026-02-16) or a relative age such as 7d,
+// 2w or 36h measured back from now
+func ParseSince(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+
+	// Ages must be positive: anything else is a window in the future
+	invalid := fmt.Errorf("invalid time: %s (use YYYY-MM-DD or a positive age like 7d, 2w or 36h)", s)
+	switch unit := s[len(s)-1]; unit {
+	case 'd', 'w':
+		n, err := strconv.Atoi(s[:len(s)-1])
+		if err != nil || n <= 0 {
+			return time.Time{}, invalid
+		}
+		if unit == 'w' {
+			n *= 7
+		}
+		return now.AddDate(0, 0, -n), nil
+	}
+	if d, err := time.ParseDuration(s); err == nil && d > 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, invalid
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
@@ -0,0 +1,124 @@
+package sessions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+)
+
+func TestFilterMatchesAgentWorkdirModelAndRange(t *testing.T) {
+	now := time.Date(2026, 2, 18, 12, 0, 0, 0, time.Local)
+	r := Report{
+		AgentID:   "claude-code",
+		AgentName: "Claude Code",
+		WorkDir:   "/src/agentmetrics",
+		Model:     "claude-sonnet-4",
+		EndedAt:   now,
+	}
+
+	match := []Filter{
+		{},
+		{Agent: "claude-code"},
+		{Agent: "claude"},
+		{WorkDir: "agentmetrics"},
+		{Model: "SONNET"},
+		{Since: now.Add(-time.Hour), Until: now.Add(time.Hour)},
+	}
+	for _, f := range match {
+		if !f.Match(r) {
+			t.Fatalf("expected %+v to match", f)
+		}
+	}
+
+	miss := []Filter{
+		{Agent: "aider"},
+		{WorkDir: "/other"},
+		{Model: "gpt"},
+		{Since: now.Add(time.Minute)},
+		{Until: now},
+	}
+	for _, f := range miss {
+		if f.Match(r) {
+			t.Fatalf("expected %+v not to match", f)
+		}
+	}
+}
+
+func TestAggregateByDayAndWeek(t *testing.T) {
+	mon := time.Date(2026, 2, 16, 10, 0, 0, 0, time.Local)
+	reports := []Report{
+		{EndedAt: mon, TotalTokens: 100, Cost: 1, ActiveSecs: 60},
+		{EndedAt: mon.Add(3 * time.Hour), TotalTokens: 50, Cost: 0.5, ActiveSecs: 30},
+		{EndedAt: mon.AddDate(0, 0, 2), TotalTokens: 10, Cost: 0.1},
+		{EndedAt: mon.AddDate(0, 0, 7), TotalTokens: 1, Cost: 0.01},
+	}
+
+	days := Aggregate(reports, PeriodDay)
+	if len(days) != 3 {
+		t.Fatalf("expected 3 day buckets, got %d", len(days))
+	}
+	if days[0].Period != "2026-02-16" || days[0].Sessions != 2 || days[0].TotalTokens != 150 || days[0].ActiveSecs != 90 {
+		t.Fatalf("unexpected first day bucket: %+v", days[0])
+	}
+
+	weeks := Aggregate(reports, PeriodWeek)
+	if len(weeks) != 2 {
+		t.Fatalf("expected 2 week buckets, got %d", len(weeks))
+	}
+	if weeks[0].Period != "2026-W08" || weeks[0].Sessions != 3 {
+		t.Fatalf("unexpected first week bucket: %+v", weeks[0])
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2026, 2, 18, 12, 0, 0, 0, time.Local)
+
+	cases := map[string]time.Time{
+		"2026-02-01": time.Date(2026, 2, 1, 0, 0, 0, 0, time.Local),
+		"7d":         now.AddDate(0, 0, -7),
+		"2w":         now.AddDate(0, 0, -14),
+		"36h":        now.Add(-36 * time.Hour),
+	}
+	for in, want := range cases {
+		got, err := ParseSince(in, now)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", in, err)
+		}
+		if !got.Equal(want) {
+			t.Fatalf("%s: expected %s, got %s", in, want, got)
+		}
+	}
+
+	for _, in := range []string{"last tuesday", "1.5d", "2xw", "0d", "-3d", "-2h", "0h", "d"} {
+		if _, err := ParseSince(in, now); err == nil {
+			t.Fatalf("%s: expected an error", in)
+		}
+	}
+}
+
+func TestAggregateIncludesLiveAgentWithoutReport(t *testing.T) {
+	now := time.Date(2026, 2, 18, 12, 0, 0, 0, time.Local)
+	finished := Report{AgentID: "aider", EndedAt: now.Add(-2 * time.Hour), TotalTokens: 100, Cost: 1}
+
+	running := agent.Instance{PID: 42, WorkDir: "/src/agentmetrics"}
+	running.Info.ID = "claude-code"
+	running.Tokens.TotalTokens = 500
+	running.Tokens.EstCost = 2.5
+	running.Session.StartedAt = now.Add(-time.Hour)
+
+	live := Live([]agent.Instance{running}, now)
+	if len(live) != 1 || !live[0].Live || live[0].EndedAt != now || live[0].StartedAt != running.Session.StartedAt {
+		t.Fatalf("expected one in-progress report ending now, got %+v", live)
+	}
+
+	reports := Filter{Agent: "claude-code"}.Apply(append([]Report{finished}, live...))
+	buckets := Aggregate(reports, PeriodDay)
+	if len(buckets) != 1 {
+		t.Fatalf("expected one bucket, got %+v", buckets)
+	}
+	b := buckets[0]
+	if b.Sessions != 1 || b.Live != 1 || b.TotalTokens != 500 || b.Cost != 2.5 {
+		t.Fatalf("expected the running agent alone, got %+v", b)
+	}
+}
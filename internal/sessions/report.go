@@ -0,0 +1,202 @@
+package sessions
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Report is the final summary of one agent process, written when it exits
+type Report struct {
+	ID        string    `json:"id"`
+	AgentID   string    `json:"agent_id"`
+	AgentName string    `json:"agent_name"`
+	PID       int       `json:"pid"`
+	WorkDir   string    `json:"workdir"`
+	Model     string    `json:"model"`
+	Branch    string    `json:"branch"`
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at"`
+
+	// Durations in whole seconds so reports stay easy to query with jq
+	DurationSecs int64 `json:"duration_secs"`
+	ActiveSecs   int64 `json:"active_secs"`
+	IdleSecs     int64 `json:"idle_secs"`
+
+	InputTokens  int64   `json:"input_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+	TotalTokens  int64   `json:"total_tokens"`
+	Requests     int     `json:"requests"`
+	Cost         float64 `json:"cost_usd"`
+	// DailyCost is Cost split by the day it was spent; reports written
+	// before it existed leave it empty
+	DailyCost DailyCost `json:"daily_cost_usd,omitempty"`
+
+	LOCAdded   int `json:"loc_added"`
+	LOCRemoved int `json:"loc_removed"`
+	Commits    int `json:"commits"`
+	Commands   int `json:"commands"`
+
+	FilesTouched   []string       `json:"files_touched"`
+	SecurityEvents map[string]int `json:"security_events"`
+
+	// Live marks an in-progress summary of a running agent; these are
+	// never saved
+	Live bool `json:"live,omitempty"`
+	// Interrupted marks a report written because the collector stopped
+	// while the agent was still running; EndedAt is when it stopped
+	// watching. A later report of the same session replaces it.
+	Interrupted bool `json:"interrupted,omitempty"`
+}
+
+// CostSince returns what the session spent from since's day on. Reports
+// without a daily split count in full if they ended after since.
+func (r Report) CostSince(since time.Time) float64 {
+	if r.DailyCost != nil {
+		return r.DailyCost.Since(since)
+	}
+	if r.EndedAt.Before(since) {
+		return 0
+	}
+	return r.Cost
+}
+
+// SecurityEventCount returns the total number of security events
+func (r Report) SecurityEventCount() int {
+	total := 0
+	for _, n := range r.SecurityEvents {
+		total += n
+	}
+	return total
+}
+
+// Store reads and writes reports as one JSON file each
+type Store struct {
+	dir string
+}
+
+// DirFor returns the reports directory inside a HistoryStore data dir
+func DirFor(historyDir string) string {
+	return filepath.Join(historyDir, "sessions")
+}
+
+// NewStore returns a store rooted at dir
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Dir returns the directory reports are written to
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Save writes a report, assigning its ID if empty. The ID identifies the
+// session, so when several collectors watch the same agent the first
+// report wins and the others get an error wrapping os.ErrExist. Only an
+// interrupted report is replaced.
+func (s *Store) Save(r *Report) error {
+	if r.ID == "" {
+		r.ID = reportID(*r)
+	}
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("creating sessions dir: %w", err)
+	}
+
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding report: %w", err)
+	}
+	path := filepath.Join(s.dir, r.ID+".json")
+
+	existing, err := s.Load(r.ID)
+	replace := err == nil
+	if replace && !existing.Interrupted {
+		return fmt.Errorf("report %s: %w", r.ID, os.ErrExist)
+	}
+
+	// Write aside first so readers never see half a report
+	tmp, err := os.CreateTemp(s.dir, ".report-*")
+	if err != nil {
+		return fmt.Errorf("writing report: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing report: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing report: %w", err)
+	}
+
+	if replace {
+		err = os.Rename(tmp.Name(), path)
+	} else {
+		// Link fails if another collector saved the session meanwhile
+		err = os.Link(tmp.Name(), path)
+	}
+	if err != nil {
+		return fmt.Errorf("writing report %s: %w", r.ID, err)
+	}
+	return nil
+}
+
+// Load reads a report by ID
+func (s *Store) Load(id string) (Report, error) {
+	var r Report
+	data, err := os.ReadFile(filepath.Join(s.dir, filepath.Base(id)+".json"))
+	if err != nil {
+		return r, fmt.Errorf("reading report %s: %w", id, err)
+	}
+	if err := json.Unmarshal(data, &r); err != nil {
+		return r, fmt.Errorf("parsing report %s: %w", id, err)
+	}
+	return r, nil
+}
+
+// List returns every readable report, most recent first
+func (s *Store) List() ([]Report, error) {
+	entries, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("listing sessions: %w", err)
+	}
+
+	var reports []Report
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		r, err := s.Load(strings.TrimSuffix(e.Name(), ".json"))
+		if err != nil {
+			continue
+		}
+		reports = append(reports, r)
+	}
+
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].EndedAt.After(reports[j].EndedAt)
+	})
+	return reports, nil
+}
+
+// reportID builds a filesystem-safe identifier of the session: the same
+// process and start time give the same ID whichever collector saw it
+func reportID(r Report) string {
+	agentID := r.AgentID
+	if agentID == "" {
+		agentID = "agent"
+	}
+	agentID = strings.Map(func(c rune) rune {
+		if c == '/' || c == os.PathSeparator || c == ' ' {
+			return '_'
+		}
+		return c
+	}, agentID)
+	return fmt.Sprintf("%s-%s-%d", r.StartedAt.Format("20060102-150405"), agentID, r.PID)
+}
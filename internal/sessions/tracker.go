@@ -0,0 +1,196 @@
+package sessions
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+)
+
+// tracked accumulates what a live agent did across scans. Detector results
+// only hold the latest snapshot, so files, commits and security events are
+// collected here while the process is alive.
+type tracked struct {
+	last      agent.Instance
+	firstSeen time.Time
+	files     map[string]struct{}
+	commits   map[string]struct{}
+	security  map[string]int
+}
+
+// Tracker notices agent PIDs leaving the detector results and turns
+// their accumulated metrics into reports
+type Tracker struct {
+	live         map[int]*tracked
+	lastSecurity time.Time
+	costs        *Ledger
+}
+
+// NewTracker creates an empty tracker
+func NewTracker() *Tracker {
+	return &Tracker{live: map[int]*tracked{}, costs: NewLedger()}
+}
+
+// Observe records the current scan and returns reports for every agent
+// that was present last time but is gone now
+func (t *Tracker) Observe(agents []agent.Instance, events []agent.SecurityEvent, now time.Time) []Report {
+	present := make(map[int]bool, len(agents))
+	for _, a := range agents {
+		present[a.PID] = true
+
+		tr, ok := t.live[a.PID]
+		if !ok {
+			tr = &tracked{
+				firstSeen: now,
+				files:     map[string]struct{}{},
+				commits:   map[string]struct{}{},
+				security:  map[string]int{},
+			}
+			t.live[a.PID] = tr
+		}
+		tr.last = a
+
+		for _, op := range a.FileOps {
+			tr.files[op.Path] = struct{}{}
+		}
+		for _, c := range a.Git.RecentCommits {
+			// Only count commits made while the agent was running
+			if !a.Session.StartedAt.IsZero() && c.Time.Before(a.Session.StartedAt) {
+				continue
+			}
+			tr.commits[c.Hash] = struct{}{}
+		}
+	}
+
+	t.countSecurity(agents, events)
+	t.costs.Observe(agents, now)
+
+	var reports []Report
+	for pid, tr := range t.live {
+		if present[pid] {
+			continue
+		}
+		r := tr.report(now)
+		r.DailyCost = t.costs.Days(pid)
+		reports = append(reports, r)
+		delete(t.live, pid)
+	}
+	t.costs.Forget(agents)
+	sort.Slice(reports, func(i, j int) bool { return reports[i].PID < reports[j].PID })
+	return reports
+}
+
+// Flush returns interrupted reports for every agent still running, for a
+// collector that is shutting down, and forgets them
+func (t *Tracker) Flush(now time.Time) []Report {
+	reports := make([]Report, 0, len(t.live))
+	for pid, tr := range t.live {
+		r := tr.report(now)
+		r.Interrupted = true
+		r.DailyCost = t.costs.Days(pid)
+		reports = append(reports, r)
+		delete(t.live, pid)
+	}
+	t.costs.Forget(nil)
+	sort.Slice(reports, func(i, j int) bool { return reports[i].PID < reports[j].PID })
+	return reports
+}
+
+// WithoutRunning drops interrupted reports of agents that are running
+// again: their live state already covers the session
+func WithoutRunning(reports []Report, agents []agent.Instance) []Report {
+	running := make(map[string]bool, len(agents))
+	for _, a := range agents {
+		running[fmt.Sprintf("%s|%d", a.Info.ID, a.PID)] = true
+	}
+	var out []Report
+	for _, r := range reports {
+		if r.Interrupted && running[fmt.Sprintf("%s|%d", r.AgentID, r.PID)] {
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// Live summarises agents that are still running as if they ended now, so
+// aggregates can include sessions without a report yet. Files and commits
+// come from the current snapshot only.
+func Live(agents []agent.Instance, now time.Time) []Report {
+	t := NewTracker()
+	t.Observe(agents, nil, now)
+
+	reports := make([]Report, 0, len(t.live))
+	for _, a := range agents {
+		if tr, ok := t.live[a.PID]; ok {
+			r := tr.report(now)
+			r.Live = true
+			reports = append(reports, r)
+			delete(t.live, a.PID)
+		}
+	}
+	return reports
+}
+
+// countSecurity attributes new events to the first live PID with the
+// event's agent ID
+func (t *Tracker) countSecurity(agents []agent.Instance, events []agent.SecurityEvent) {
+	newest := t.lastSecurity
+	for _, evt := range events {
+		if !evt.Timestamp.After(t.lastSecurity) {
+			continue
+		}
+		if evt.Timestamp.After(newest) {
+			newest = evt.Timestamp
+		}
+		for _, a := range agents {
+			if a.Info.ID == evt.AgentID {
+				t.live[a.PID].security[string(evt.Severity)]++
+				break
+			}
+		}
+	}
+	t.lastSecurity = newest
+}
+
+// report builds the final summary from the last snapshot seen
+func (tr *tracked) report(ended time.Time) Report {
+	a := tr.last
+
+	started := a.Session.StartedAt
+	if started.IsZero() {
+		started = tr.firstSeen
+	}
+
+	files := make([]string, 0, len(tr.files))
+	for f := range tr.files {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+
+	return Report{
+		AgentID:        a.Info.ID,
+		AgentName:      a.Info.Name,
+		PID:            a.PID,
+		WorkDir:        a.WorkDir,
+		Model:          a.Tokens.LastModel,
+		Branch:         a.Git.Branch,
+		StartedAt:      started,
+		EndedAt:        ended,
+		DurationSecs:   int64(ended.Sub(started).Seconds()),
+		ActiveSecs:     int64(a.Session.ActiveTime.Seconds()),
+		IdleSecs:       int64(a.Session.IdleTime.Seconds()),
+		InputTokens:    a.Tokens.InputTokens,
+		OutputTokens:   a.Tokens.OutputTokens,
+		TotalTokens:    a.Tokens.TotalTokens,
+		Requests:       a.Tokens.RequestCount,
+		Cost:           a.Tokens.EstCost,
+		LOCAdded:       a.LOC.Added,
+		LOCRemoved:     a.LOC.Removed,
+		Commits:        len(tr.commits),
+		Commands:       a.Terminal.TotalCommands,
+		FilesTouched:   files,
+		SecurityEvents: tr.security,
+	}
+}
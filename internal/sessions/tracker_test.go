@@ -0,0 +1,174 @@
+package sessions
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+)
+
+func testAgent(pid int) agent.Instance {
+	a := agent.Instance{PID: pid, Info: agent.Info{ID: "claude-code", Name: "Claude Code"}, WorkDir: "/src/repo"}
+	a.Tokens.TotalTokens = 1000
+	a.Tokens.EstCost = 0.25
+	return a
+}
+
+func TestTrackerReportsExitedAgent(t *testing.T) {
+	tr := NewTracker()
+	now := time.Now()
+
+	a := testAgent(10)
+	a.Session.StartedAt = now.Add(-time.Hour)
+	a.FileOps = []agent.FileOperation{{Path: "/src/repo/main.go"}}
+
+	if reports := tr.Observe([]agent.Instance{a, testAgent(11)}, nil, now); len(reports) != 0 {
+		t.Fatalf("expected no reports while agents run, got %d", len(reports))
+	}
+
+	a.FileOps = []agent.FileOperation{{Path: "/src/repo/util.go"}}
+	events := []agent.SecurityEvent{{Timestamp: now, AgentID: "claude-code", Severity: agent.SecSevHigh}}
+	tr.Observe([]agent.Instance{a, testAgent(11)}, events, now.Add(time.Second))
+	tr.Observe([]agent.Instance{a, testAgent(11)}, events, now.Add(2*time.Second))
+
+	reports := tr.Observe([]agent.Instance{testAgent(11)}, events, now.Add(time.Minute))
+	if len(reports) != 1 {
+		t.Fatalf("expected 1 report, got %d", len(reports))
+	}
+
+	r := reports[0]
+	if r.PID != 10 || r.TotalTokens != 1000 {
+		t.Fatalf("expected report for PID 10 with 1000 tokens, got %+v", r)
+	}
+	if len(r.FilesTouched) != 2 {
+		t.Fatalf("expected files from every scan, got %v", r.FilesTouched)
+	}
+	if r.SecurityEvents[string(agent.SecSevHigh)] != 1 {
+		t.Fatalf("expected 1 high security event counted once, got %v", r.SecurityEvents)
+	}
+
+	if reports := tr.Observe([]agent.Instance{testAgent(11)}, nil, now.Add(2*time.Minute)); len(reports) != 0 {
+		t.Fatalf("expected exited agent to be reported once, got %d", len(reports))
+	}
+}
+
+func TestStoreSaveListLoad(t *testing.T) {
+	store := NewStore(t.TempDir())
+	now := time.Now()
+
+	older := Report{AgentID: "aider", PID: 1, EndedAt: now.Add(-time.Hour)}
+	newer := Report{AgentID: "claude-code", PID: 2, EndedAt: now}
+	for _, r := range []*Report{&older, &newer} {
+		if err := store.Save(r); err != nil {
+			t.Fatalf("saving report: %v", err)
+		}
+	}
+
+	reports, err := store.List()
+	if err != nil {
+		t.Fatalf("listing reports: %v", err)
+	}
+	if len(reports) != 2 || reports[0].PID != 2 {
+		t.Fatalf("expected newest report first, got %+v", reports)
+	}
+
+	loaded, err := store.Load(older.ID)
+	if err != nil {
+		t.Fatalf("loading report: %v", err)
+	}
+	if loaded.AgentID != "aider" {
+		t.Fatalf("expected aider report, got %+v", loaded)
+	}
+}
+
+func TestStoreListMissingDir(t *testing.T) {
+	reports, err := NewStore(t.TempDir() + "/missing").List()
+	if err != nil || len(reports) != 0 {
+		t.Fatalf("expected empty list for missing dir, got %v, %v", reports, err)
+	}
+}
+
+func TestStoreKeepsOneReportPerSession(t *testing.T) {
+	store := NewStore(t.TempDir())
+	started := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+
+	interrupted := Report{AgentID: "claude-code", PID: 7, StartedAt: started, EndedAt: started.Add(time.Hour), Interrupted: true}
+	if err := store.Save(&interrupted); err != nil {
+		t.Fatalf("saving report: %v", err)
+	}
+
+	// The final report of the same session replaces the interrupted one
+	final := Report{AgentID: "claude-code", PID: 7, StartedAt: started, EndedAt: started.Add(2 * time.Hour), Cost: 1}
+	if err := store.Save(&final); err != nil {
+		t.Fatalf("saving report: %v", err)
+	}
+
+	// A second collector that watched the same session is turned away
+	again := Report{AgentID: "claude-code", PID: 7, StartedAt: started, EndedAt: started.Add(2*time.Hour + time.Second), Cost: 1}
+	if err := store.Save(&again); !errors.Is(err, os.ErrExist) {
+		t.Fatalf("expected os.ErrExist, got %v", err)
+	}
+
+	reports, err := store.List()
+	if err != nil {
+		t.Fatalf("listing reports: %v", err)
+	}
+	if len(reports) != 1 || reports[0].Interrupted || !reports[0].EndedAt.Equal(final.EndedAt) {
+		t.Fatalf("expected only the final report, got %+v", reports)
+	}
+}
+
+func TestTrackerFlushesRunningAgents(t *testing.T) {
+	tr := NewTracker()
+	now := time.Now()
+	tr.Observe([]agent.Instance{testAgent(10), testAgent(11)}, nil, now)
+
+	reports := tr.Flush(now.Add(time.Minute))
+	if len(reports) != 2 || !reports[0].Interrupted || reports[0].PID != 10 {
+		t.Fatalf("expected interrupted reports for both agents, got %+v", reports)
+	}
+	if len(tr.Flush(now.Add(2*time.Minute))) != 0 {
+		t.Fatal("expected flushed agents to be forgotten")
+	}
+
+	// An interrupted session that is running again is covered by its live state
+	kept := WithoutRunning(append(reports, Report{AgentID: "claude-code", PID: 12}), []agent.Instance{testAgent(10)})
+	if len(kept) != 2 || kept[0].PID != 11 || kept[1].PID != 12 {
+		t.Fatalf("expected PID 10 dropped, got %+v", kept)
+	}
+}
+
+func TestLedgerBooksCostByDay(t *testing.T) {
+	l := NewLedger()
+	evening := time.Date(2026, 2, 17, 23, 0, 0, 0, time.Local)
+
+	a := testAgent(10)
+	a.Tokens.EstCost = 4
+	a.Session.StartedAt = evening.Add(-time.Hour)
+	unknown := testAgent(11)
+	unknown.Tokens.EstCost = 9
+
+	l.Observe([]agent.Instance{a, unknown}, evening)
+	a.Tokens.EstCost = 5
+	unknown.Tokens.EstCost = 10
+	l.Observe([]agent.Instance{a, unknown}, evening.Add(2*time.Hour))
+
+	want := DailyCost{"2026-02-17": 4, "2026-02-18": 1}
+	if got := l.Days(10); len(got) != 2 || got["2026-02-17"] != 4 || got["2026-02-18"] != 1 {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	// Spend from before an agent of unknown start was seen isn't booked
+	if got := l.Days(11); len(got) != 1 || got["2026-02-18"] != 1 {
+		t.Fatalf("expected only the $1 seen spent, got %v", got)
+	}
+	if got := l.Days(10).Since(time.Date(2026, 2, 18, 0, 0, 0, 0, time.Local)); got != 1 {
+		t.Fatalf("expected $1 since midnight, got %v", got)
+	}
+
+	l.Forget([]agent.Instance{a})
+	if l.Days(11) != nil {
+		t.Fatal("expected the exited agent to be forgotten")
+	}
+}
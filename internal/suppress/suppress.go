@@ -0,0 +1,172 @@
+package suppress
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+	"github.com/rafaelperezbeato/agentmetrics/internal/appconfig"
+)
+
+// Count reports how many distinct events a rule has suppressed
+type Count struct {
+	Rule       string `json:"rule"`
+	Suppressed int    `json:"suppressed"`
+	Expired    bool   `json:"expired,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+type rule struct {
+	cfg     appconfig.Suppression
+	command *regexp.Regexp
+	workDir string
+	err     error
+}
+
+// Filter applies suppression rules to security events. The monitor returns
+// the same recent events on every scan, so each event is counted once.
+type Filter struct {
+	rules  []rule
+	counts []int
+	seen   map[string]int
+}
+
+// New compiles the rules. A rule with an invalid regex never matches and
+// reports the error in its Count.
+func New(cfg []appconfig.Suppression) *Filter {
+	f := &Filter{
+		counts: make([]int, len(cfg)),
+		seen:   map[string]int{},
+	}
+	for _, c := range cfg {
+		r := rule{cfg: c}
+		if c.Command != "" {
+			r.command, r.err = regexp.Compile(c.Command)
+			if r.err != nil {
+				r.err = fmt.Errorf("invalid command regex: %w", r.err)
+			}
+		}
+		if c.WorkDir != "" {
+			r.workDir = filepath.Clean(appconfig.ExpandHome(c.WorkDir))
+		}
+		f.rules = append(f.rules, r)
+	}
+	return f
+}
+
+// Apply returns the events no rule matches. agents supplies working
+// directories for the workdir globs.
+func (f *Filter) Apply(events []agent.SecurityEvent, agents []agent.Instance, now time.Time) []agent.SecurityEvent {
+	if len(f.rules) == 0 {
+		return events
+	}
+
+	workDirs := map[string][]string{}
+	for _, a := range agents {
+		workDirs[a.Info.ID] = append(workDirs[a.Info.ID], a.WorkDir)
+	}
+
+	var kept []agent.SecurityEvent
+	seen := make(map[string]int, len(f.seen))
+	for _, evt := range events {
+		key := fmt.Sprintf("%d|%s|%s", evt.Timestamp.UnixNano(), evt.AgentID, evt.Detail)
+		if i, ok := f.seen[key]; ok {
+			// Decided on an earlier scan
+			seen[key] = i
+			if i < 0 {
+				kept = append(kept, evt)
+			}
+			continue
+		}
+
+		i := f.match(evt, workDirs[evt.AgentID], now)
+		seen[key] = i
+		if i < 0 {
+			kept = append(kept, evt)
+			continue
+		}
+		f.counts[i]++
+	}
+	// Events that left the monitor's window will not come back
+	f.seen = seen
+	return kept
+}
+
+// Rule returns the name of the first rule that would suppress evt,
+// without counting it
+func (f *Filter) Rule(evt agent.SecurityEvent, workDirs []string, now time.Time) (string, bool) {
+	if i := f.match(evt, workDirs, now); i >= 0 {
+		return f.rules[i].cfg.Name, true
+	}
+	return "", false
+}
+
+// match returns the index of the first rule matching evt, or -1
+func (f *Filter) match(evt agent.SecurityEvent, workDirs []string, now time.Time) int {
+	for i, r := range f.rules {
+		if r.matches(evt, workDirs, now) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (r rule) matches(evt agent.SecurityEvent, workDirs []string, now time.Time) bool {
+	c := r.cfg
+	if r.err != nil || (!c.Expires.IsZero() && now.After(c.Expires)) {
+		return false
+	}
+	if c.Category != "" && !strings.EqualFold(c.Category, string(evt.Category)) {
+		return false
+	}
+	if c.AgentID != "" && c.AgentID != evt.AgentID {
+		return false
+	}
+	if c.Pattern != "" {
+		p := strings.ToLower(c.Pattern)
+		if !strings.Contains(strings.ToLower(evt.Detail), p) && !strings.Contains(strings.ToLower(evt.Description), p) {
+			return false
+		}
+	}
+	if r.command != nil && !r.command.MatchString(evt.Detail) {
+		return false
+	}
+	if r.workDir != "" && !anyDirMatches(r.workDir, workDirs) {
+		return false
+	}
+	return true
+}
+
+// anyDirMatches reports whether glob matches one of dirs or a parent of it
+func anyDirMatches(glob string, dirs []string) bool {
+	for _, dir := range dirs {
+		for d := filepath.Clean(dir); ; d = filepath.Dir(d) {
+			if ok, _ := filepath.Match(glob, d); ok {
+				return true
+			}
+			if d == filepath.Dir(d) {
+				break
+			}
+		}
+	}
+	return false
+}
+
+// Counts returns per-rule totals in config order
+func (f *Filter) Counts(now time.Time) []Count {
+	out := make([]Count, len(f.rules))
+	for i, r := range f.rules {
+		out[i] = Count{
+			Rule:       r.cfg.Name,
+			Suppressed: f.counts[i],
+			Expired:    !r.cfg.Expires.IsZero() && now.After(r.cfg.Expires),
+		}
+		if r.err != nil {
+			out[i].Error = r.err.Error()
+		}
+	}
+	return out
+}
@@ -0,0 +1,60 @@
+package suppress
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+	"github.com/rafaelperezbeato/agentmetrics/internal/appconfig"
+)
+
+func event(agentID, category, detail string) agent.SecurityEvent {
+	return agent.SecurityEvent{
+		Timestamp:   time.Date(2026, 2, 18, 12, 0, 0, 0, time.UTC),
+		Severity:    agent.SecSevHigh,
+		Category:    agent.SecurityCategory(category),
+		Description: "Remote access",
+		Detail:      detail,
+		AgentID:     agentID,
+	}
+}
+
+func TestApplySuppressesMatchingEventsAndCountsOnce(t *testing.T) {
+	now := time.Date(2026, 2, 18, 12, 0, 0, 0, time.UTC)
+	f := New([]appconfig.Suppression{
+		{Name: "deploy ssh", Category: "remote_access", AgentID: "claude-code", WorkDir: "/src/*", Command: `^(ssh|rsync) .*deploy@`},
+		{Name: "path", Pattern: "export path="},
+		{Name: "old", Pattern: "sudo", Expires: now.Add(-time.Hour)},
+		{Name: "broken", Command: "("},
+	})
+
+	a := agent.Instance{WorkDir: "/src/api/cmd"}
+	a.Info.ID = "claude-code"
+	agents := []agent.Instance{a}
+
+	events := []agent.SecurityEvent{
+		event("claude-code", "remote_access", "ssh deploy@prod"),
+		event("claude-code", "remote_access", "ssh root@prod"),
+		event("aider", "remote_access", "rsync -a . deploy@prod:"),
+		event("aider", "env_manipulation", "export PATH=/tmp:$PATH"),
+		event("aider", "escalation", "sudo make install"),
+	}
+
+	for i := 0; i < 2; i++ {
+		kept := f.Apply(events, agents, now)
+		if len(kept) != 3 {
+			t.Fatalf("scan %d: expected 3 kept events, got %+v", i, kept)
+		}
+	}
+
+	counts := f.Counts(now)
+	if counts[0].Suppressed != 1 || counts[1].Suppressed != 1 {
+		t.Fatalf("expected each event counted once, got %+v", counts)
+	}
+	if !counts[2].Expired || counts[2].Suppressed != 0 {
+		t.Fatalf("expected expired rule to be inactive, got %+v", counts[2])
+	}
+	if counts[3].Error == "" {
+		t.Fatalf("expected invalid regex to be reported")
+	}
+}
@@ -0,0 +1,116 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+)
+
+// sortCycle is the order the sort key steps through, ending back at the
+// scan order
+var sortCycle = []string{"cpu", "mem", "cost", "tokens", "uptime", "name", ""}
+
+// agentSort is the column the agent list is ordered by; an empty key
+// keeps the scan order
+type agentSort struct {
+	key  string
+	desc bool
+}
+
+// column returns the table column the sort key refers to
+func (t agentSort) column() (tableColumn, bool) {
+	i := slices.IndexFunc(tableColumns, func(c tableColumn) bool { return c.key == t.key })
+	if i < 0 {
+		return tableColumn{}, false
+	}
+	return tableColumns[i], true
+}
+
+// by sorts on the given column, starting numeric columns high-to-low
+func (t agentSort) by(key string) agentSort {
+	t.key = key
+	c, _ := t.column()
+	t.desc = c.right
+	return t
+}
+
+// cycle steps to the next key of sortCycle
+func (t agentSort) cycle() agentSort {
+	return t.by(nextValue(sortCycle, t.key))
+}
+
+// next moves the sort to the column step places away among cols
+func (t agentSort) next(cols []tableColumn, step int) agentSort {
+	i := slices.IndexFunc(cols, func(c tableColumn) bool { return c.key == t.key })
+	switch {
+	case i < 0 && step < 0:
+		i = len(cols) - 1
+	case i < 0:
+		i = 0
+	default:
+		i = ((i+step)%len(cols) + len(cols)) % len(cols)
+	}
+	return t.by(cols[i].key)
+}
+
+// label describes the sort for the dashboard, e.g. "CPU ▼"
+func (t agentSort) label() string {
+	c, ok := t.column()
+	if !ok {
+		return ""
+	}
+	if t.desc {
+		return c.title + " ▼"
+	}
+	return c.title + " ▲"
+}
+
+// apply returns agents ordered by the sort column. Ties keep scan order.
+func (t agentSort) apply(agents []agent.Instance) []agent.Instance {
+	c, ok := t.column()
+	if !ok {
+		return agents
+	}
+	sorted := slices.Clone(agents)
+	slices.SortStableFunc(sorted, func(a, b agent.Instance) int {
+		if t.desc {
+			return c.compare(b, a)
+		}
+		return c.compare(a, b)
+	})
+	return sorted
+}
+
+// agentFilter narrows the agent list to those whose name, working
+// directory, model or branch contain the query
+type agentFilter struct {
+	query string
+	// editing is true while the query is being typed after /
+	editing bool
+}
+
+// matches reports whether a passes the filter; the match ignores case
+func (f agentFilter) matches(a agent.Instance) bool {
+	q := strings.ToLower(f.query)
+	for _, field := range []string{a.Info.Name, a.WorkDir, a.Tokens.LastModel, a.Git.Branch} {
+		if strings.Contains(strings.ToLower(field), q) {
+			return true
+		}
+	}
+	return false
+}
+
+// apply returns the agents that match
+func (f agentFilter) apply(agents []agent.Instance) []agent.Instance {
+	if f.query == "" {
+		return agents
+	}
+	var out []agent.Instance
+	for _, a := range agents {
+		if f.matches(a) {
+			out = append(out, a)
+		}
+	}
+	return out
+}
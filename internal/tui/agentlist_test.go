@@ -0,0 +1,148 @@
+package tui
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+)
+
+func testAgents() []agent.Instance {
+	claude := agent.Instance{PID: 10, CPU: 5, WorkDir: "/src/web"}
+	claude.Info.Name = "Claude Code"
+	claude.Tokens.LastModel = "sonnet"
+	aider := agent.Instance{PID: 20, CPU: 40, WorkDir: "/src/api"}
+	aider.Info.Name = "aider"
+	aider.Git.Branch = "fix-login"
+	copilot := agent.Instance{PID: 30, CPU: 5, WorkDir: "/src/web"}
+	copilot.Info.Name = "Copilot"
+	return []agent.Instance{claude, aider, copilot}
+}
+
+func pids(agents []agent.Instance) []int {
+	out := make([]int, len(agents))
+	for i, a := range agents {
+		out[i] = a.PID
+	}
+	return out
+}
+
+func TestAgentSortNext(t *testing.T) {
+	cols := visibleColumns(1000, showAll)
+
+	tests := []struct {
+		name     string
+		from     agentSort
+		step     int
+		wantKey  string
+		wantDesc bool
+	}{
+		{"from scan order", agentSort{}, 1, "name", false},
+		{"backwards from scan order", agentSort{}, -1, "dir", false},
+		// Right-aligned columns are numbers, sorted high to low first
+		{"to a numeric column", agentSort{key: "status"}, 1, "pid", true},
+		{"wraps forwards", agentSort{key: "dir"}, 1, "name", false},
+		{"wraps backwards", agentSort{key: "name", desc: true}, -1, "dir", false},
+		{"hidden column restarts", agentSort{key: "gone"}, 1, "name", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.from.next(cols, tt.step)
+			if got.key != tt.wantKey || got.desc != tt.wantDesc {
+				t.Fatalf("expected %s desc=%v, got %+v", tt.wantKey, tt.wantDesc, got)
+			}
+		})
+	}
+}
+
+func TestAgentSortApply(t *testing.T) {
+	agents := testAgents()
+
+	tests := []struct {
+		name string
+		sort agentSort
+		want []int
+	}{
+		{"scan order", agentSort{}, []int{10, 20, 30}},
+		{"name ignores case", agentSort{}.by("name"), []int{20, 10, 30}},
+		{"name descending", agentSort{key: "name", desc: true}, []int{30, 10, 20}},
+		// Claude and Copilot tie on CPU and keep their scan order
+		{"cpu ties", agentSort{}.by("cpu"), []int{20, 10, 30}},
+		{"cpu ascending ties", agentSort{key: "cpu"}, []int{10, 30, 20}},
+		{"unknown key", agentSort{key: "gone"}, []int{10, 20, 30}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pids(tt.sort.apply(agents)); !slices.Equal(got, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+	if got := pids(agents); !slices.Equal(got, []int{10, 20, 30}) {
+		t.Fatalf("apply must not reorder its input, got %v", got)
+	}
+}
+
+func TestAgentFilter(t *testing.T) {
+	agents := testAgents()
+
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		{"", []int{10, 20, 30}},
+		{"CLAUDE", []int{10}},
+		{"/src/web", []int{10, 30}},
+		{"sonnet", []int{10}},
+		{"login", []int{20}},
+		{"nothing", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got := agentFilter{query: tt.query}.apply(agents)
+			if !slices.Equal(pids(got), tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, pids(got))
+			}
+		})
+	}
+}
+
+func TestRefreshListKeepsSelectionOnItsAgent(t *testing.T) {
+	m := Model{scanned: testAgents(), viewports: map[View]viewport{}, pageOpts: map[View]pageOptions{}}
+	m.refreshList()
+	m.selectAt(2)
+
+	// Reordering follows Copilot
+	m.sort = agentSort{}.by("name")
+	m.refreshList()
+	if m.selectedPID != 30 || m.selected != 2 {
+		t.Fatalf("expected Copilot at 2, got pid %d at %d", m.selectedPID, m.selected)
+	}
+	m.sort = agentSort{}.by("cpu")
+	m.refreshList()
+	if m.selectedPID != 30 || m.selected != 2 {
+		t.Fatalf("expected Copilot at 2, got pid %d at %d", m.selectedPID, m.selected)
+	}
+
+	// Filtering Copilot out re-anchors on the agent now in its place and
+	// leaves its detail view
+	m.currentView = ViewDetail
+	m.filter = agentFilter{query: "aider"}
+	m.refreshList()
+	if m.selectedPID != 20 || m.selected != 0 || m.currentView != ViewDashboard {
+		t.Fatalf("expected aider selected on the dashboard, got pid %d at %d in view %d", m.selectedPID, m.selected, m.currentView)
+	}
+
+	// Nothing matches
+	m.filter = agentFilter{query: "nothing"}
+	m.refreshList()
+	if len(m.agents) != 0 || m.selectedPID != 0 {
+		t.Fatalf("expected an empty list without a selection, got %v pid %d", pids(m.agents), m.selectedPID)
+	}
+
+	// A notification click clears the filter to show its agent
+	m.showAgent("Copilot")
+	if m.filter.query != "" || m.selectedPID != 30 || m.currentView != ViewDetail {
+		t.Fatalf("expected Copilot's detail view, got pid %d in view %d filtered by %q", m.selectedPID, m.currentView, m.filter.query)
+	}
+}
@@ -1,12 +1,29 @@
 package tui
 
 import (
+	"cmp"
+	"errors"
+	"maps"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/Rafiki81/libagentmetrics/agent"
 	"github.com/Rafiki81/libagentmetrics/config"
 	"github.com/Rafiki81/libagentmetrics/monitor"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/rafaelperezbeato/agentmetrics/internal/appconfig"
+	"github.com/rafaelperezbeato/agentmetrics/internal/budget"
+	"github.com/rafaelperezbeato/agentmetrics/internal/collector"
+	"github.com/rafaelperezbeato/agentmetrics/internal/enforce"
+	"github.com/rafaelperezbeato/agentmetrics/internal/groups"
+	"github.com/rafaelperezbeato/agentmetrics/internal/hooks"
+	"github.com/rafaelperezbeato/agentmetrics/internal/lifecycle"
+	"github.com/rafaelperezbeato/agentmetrics/internal/review"
+	"github.com/rafaelperezbeato/agentmetrics/internal/suppress"
 )
 
 // View represents current UI view
@@ -15,39 +32,62 @@ type View int
 const (
 	ViewDashboard View = iota
 	ViewDetail
+	ViewSecurity
+	ViewTimeline
 )
 
 // Model is the main Bubble Tea model
 type Model struct {
 	// Data
-	agents        []agent.Instance
-	detector      *agent.Detector
-	fileMon       *monitor.FileWatcher
-	netMon        *monitor.NetworkMonitor
-	procMon       *monitor.ProcessMonitor
-	tokenMon      *monitor.TokenMonitor
-	gitMon        *monitor.GitMonitor
-	termMon       *monitor.TerminalMonitor
-	sessionMon    *monitor.SessionMonitor
-	alertMon      *monitor.AlertMonitor
-	secMon        *monitor.SecurityMonitor
-	localModelMon *monitor.LocalModelMonitor
-	history       *monitor.HistoryStore
-	config        *config.Config
-	styles        *Styles
-	alerts        []agent.Alert
-	secEvents     []agent.SecurityEvent
-	localModels   []agent.LocalModelInfo
+	scanned   []agent.Instance
+	agents    []agent.Instance
+	collector *collector.Collector
+	remote    collector.Source
+	// history is what the export key writes: the collector's history, or
+	// the daemon snapshots seen so far when attached
+	history     *monitor.HistoryStore
+	config      *config.Config
+	styles      *Styles
+	alerts      []agent.Alert
+	secEvents   []agent.SecurityEvent
+	localModels []agent.LocalModelInfo
+	budgets     []budget.Usage
+	enforcement []enforce.Action
+	hookRuns    []hooks.Result
+	timeline    []lifecycle.Event
+	trends      *trendStore
+	secLog      []agent.SecurityEvent
+	suppressed  []suppress.Count
+	decisions   *review.Store
 
 	// UI state
 	currentView View
 	selected    int
+	selectedPID int
+	filter      agentFilter
+	secFilter   securityFilter
+	secSelected int
 	width       int
 	height      int
 
+	// Scroll position and section state of the scrollable views
+	viewports map[View]viewport
+	pageOpts  map[View]pageOptions
+	layout    string
+	sort      agentSort
+	grouped   bool
+	groups    []groups.Group
+	roots     *groups.Resolver
+
 	// Timing
 	lastRefresh time.Time
 	err         error
+
+	// Problems shown above every view until fixed: the config and
+	// decisions files that failed to load, and sinks failing to deliver
+	configErr    error
+	decisionsErr error
+	sinkErrors   []string
 }
 
 // tickMsg triggers periodic refresh
@@ -59,62 +99,53 @@ type agentScanMsg struct {
 	err    error
 }
 
-// NewModel creates the initial model
-func NewModel(cfg *config.Config) Model {
-	registry := agent.NewRegistry()
-	detector := agent.NewDetector(registry, cfg)
-	fileMon := monitor.NewFileWatcher(cfg.Monitor.MaxFileOps)
-	netMon := monitor.NewNetworkMonitor()
-	procMon := monitor.NewProcessMonitor(nil)
-	tokenMon := monitor.NewTokenMonitor()
-	gitMon := monitor.NewGitMonitor()
-	termMon := monitor.NewTerminalMonitor(cfg.Monitor.MaxTermCommands)
-	sessionMon := monitor.NewSessionMonitor()
-
-	// Build alert thresholds from config
-	thresholds := monitor.AlertThresholds{
-		CPUWarning:      cfg.Alerts.CPUWarning,
-		CPUCritical:     cfg.Alerts.CPUCritical,
-		MemoryWarning:   cfg.Alerts.MemoryWarning,
-		MemoryCritical:  cfg.Alerts.MemoryCritical,
-		TokenWarning:    cfg.Alerts.TokenWarning,
-		TokenCritical:   cfg.Alerts.TokenCritical,
-		CostWarning:     cfg.Alerts.CostWarning,
-		CostCritical:    cfg.Alerts.CostCritical,
-		IdleMinutes:     cfg.Alerts.IdleMinutes,
-		CooldownMinutes: cfg.Alerts.CooldownMinutes,
-		MaxAlerts:       cfg.Alerts.MaxAlerts,
-	}
-	alertMon := monitor.NewAlertMonitor(thresholds)
-
-	// Security monitor from config
-	secMon := monitor.NewSecurityMonitor(cfg.Security)
-
-	// Local model monitor
-	localModelMon := monitor.NewLocalModelMonitor(cfg.LocalModels)
-
-	// History store from config
-	histDir := cfg.Export.Directory
-	history := monitor.NewHistoryStore(histDir, cfg.Export.MaxHistory)
+// stateMsg carries pre-enriched state from a remote source
+type stateMsg struct {
+	state collector.State
+	err   error
+}
+
+// resolvedMsg reports the outcome of resuming or terminating a paused process
+type resolvedMsg struct {
+	err error
+}
 
+// notificationClickMsg reports a click on an agent's desktop notification
+type notificationClickMsg struct {
+	agent string
+}
+
+// NewModel creates the initial model. When remote is non-nil the model
+// attaches to it instead of running its own collection pipeline.
+func NewModel(cfg *config.Config, appCfg *appconfig.Config, remote collector.Source) Model {
 	// Build styles from theme config
 	styles := NewStyles(cfg.Theme)
 
+	// A corrupt decisions file starts empty rather than blocking the TUI,
+	// with a warning until it loads
+	decisions, decisionsErr := review.Open(review.DefaultPath())
+
+	var c *collector.Collector
+	history := monitor.NewHistoryStore(cfg.Export.Directory, cfg.Export.MaxHistory)
+	if remote == nil {
+		c = collector.New(cfg, appCfg, collector.Act)
+		history = c.History()
+	}
+
 	return Model{
-		detector:      detector,
-		fileMon:       fileMon,
-		netMon:        netMon,
-		procMon:       procMon,
-		tokenMon:      tokenMon,
-		gitMon:        gitMon,
-		termMon:       termMon,
-		sessionMon:    sessionMon,
-		alertMon:      alertMon,
-		secMon:        secMon,
-		localModelMon: localModelMon,
-		history:       history,
-		config:        cfg,
-		styles:        styles,
+		collector:    c,
+		remote:       remote,
+		history:      history,
+		config:       cfg,
+		styles:       styles,
+		decisions:    decisions,
+		decisionsErr: decisionsErr,
+		trends:       newTrendStore(appCfg.TUI.TrendWindow.Duration()),
+		viewports:    map[View]viewport{},
+		pageOpts:     map[View]pageOptions{},
+		layout:       appCfg.TUI.Layout,
+		grouped:      appCfg.TUI.Grouped,
+		roots:        groups.NewResolver(),
 	}
 }
 
@@ -132,6 +163,18 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		return m.handleKey(msg)
 
+	case tea.MouseMsg:
+		if msg.Action != tea.MouseActionPress {
+			return m, nil
+		}
+		switch msg.Button {
+		case tea.MouseButtonWheelUp:
+			m.scrollBy(-3)
+		case tea.MouseButtonWheelDown:
+			m.scrollBy(3)
+		}
+		return m, nil
+
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
@@ -146,95 +189,251 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case agentScanMsg:
 		if msg.err != nil {
 			m.err = msg.err
-		} else {
-			m.agents = msg.agents
-			m.lastRefresh = time.Now()
-
-			// Update file watcher with agent working dirs
-			for _, a := range m.agents {
-				if a.WorkDir != "" {
-					m.fileMon.AddDir(a.WorkDir)
-				}
-			}
+			return m, nil
+		}
+		m.applyState(m.collector.Enrich(msg.agents))
+		return m, nil
 
-			// Update network info
-			for i, a := range m.agents {
-				conns := m.netMon.GetConnections(a.PID)
-				m.agents[i].NetConns = conns
-			}
+	case stateMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		// Polling can return the same daemon snapshot twice
+		if msg.state.Timestamp.After(m.lastRefresh) {
+			m.history.Record(msg.state.Agents)
+		}
+		m.applyState(msg.state)
+		return m, nil
 
-			// Update file ops
-			for i, a := range m.agents {
-				if a.WorkDir != "" {
-					ops := m.fileMon.GetOperationsForDir(a.WorkDir)
-					m.agents[i].FileOps = ops
-				}
-			}
+	case resolvedMsg:
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		return m, m.scanAgents()
 
-			// Collect token metrics (includes cost + latency)
-			m.tokenMon.Collect(m.agents)
+	case notificationClickMsg:
+		m.showAgent(msg.agent)
+		return m, nil
+	}
 
-			// Collect git activity + LOC
-			for i := range m.agents {
-				m.gitMon.Collect(&m.agents[i])
-			}
+	return m, nil
+}
 
-			// Collect terminal commands
-			for i := range m.agents {
-				m.termMon.Collect(&m.agents[i])
-			}
+// applyState replaces the displayed data with a collection result
+func (m *Model) applyState(st collector.State) {
+	// Another TUI may have reviewed events; the collector filters with
+	// its own copy and reports its own load errors
+	m.decisionsErr = m.decisions.Reload()
+	if m.decisionsErr == nil && st.DecisionsError != "" {
+		m.decisionsErr = errors.New(st.DecisionsError)
+	}
+	m.scanned = st.Agents
+	m.refreshList()
+	m.alerts = st.Alerts
+	// Reviewed events stay out of the dashboard so they don't re-alert
+	m.secEvents = m.decisions.Pending(st.SecurityEvents)
+	m.secLog = mergeSecurityLog(m.secLog, st.SecurityEvents)
+	m.suppressed = st.Suppressions
+	m.localModels = st.LocalModels
+	m.budgets = st.Budgets
+	m.enforcement = st.Enforcement
+	m.hookRuns = st.Hooks
+	m.timeline = st.Lifecycle
+	m.trends.record(st.Agents, st.Timestamp)
+	m.lastRefresh = st.Timestamp
+	m.sinkErrors = st.SinkErrors
+	m.err = nil
+}
 
-			// Update session metrics
-			for i := range m.agents {
-				m.sessionMon.Collect(&m.agents[i])
-			}
+// View renders the UI
+func (m Model) View() string {
+	if m.width == 0 {
+		return "Loading..."
+	}
 
-			// Check alerts
-			if m.config.Alerts.Enabled {
-				for i := range m.agents {
-					m.alertMon.Check(&m.agents[i])
-				}
-				m.alerts = m.alertMon.GetRecentAlerts(30)
-			}
+	prompt := m.prompt()
 
-			// Security analysis (after terminal + file + network data is collected)
-			if m.config.Security.Enabled {
-				for i := range m.agents {
-					m.secMon.CheckAgent(&m.agents[i])
-				}
-				m.secEvents = m.secMon.GetRecentEvents(60)
-			}
+	switch m.currentView {
+	case ViewSecurity:
+		events := m.secFilter.apply(m.secLog, m.decisions)
+		return prompt + RenderSecurity(events, m.decisions, m.suppressed, m.secFilter, m.secSelected, m.width, m.height, m.styles)
+	case ViewTimeline:
+		return prompt + RenderTimeline(m.timeline, m.width, m.height, m.styles)
+	}
+
+	view := m.pageView()
+	p := m.page(view)
+	vp := m.viewports[view]
+	height := m.bodyHeight(prompt)
+	help := renderHelp(m.width, m.layout, vp.position(p, height), m.styles)
+	switch {
+	case view == ViewDetail:
+		help = renderDetailHelp(m.width, vp.position(p, height), m.styles)
+	case m.filter.editing:
+		help = renderFilterPrompt(m.filter, len(m.agents), len(m.scanned), m.width, m.styles)
+	}
+	return prompt + vp.window(p, height) + "\n" + help
+}
+
+// prompt renders the banners shown above every view. A paused process
+// waits on the user, whatever the view.
+func (m Model) prompt() string {
+	prompt := ""
+	if a, ok := m.pendingAction(); ok {
+		prompt = renderEnforcementPrompt(a, m.width, m.styles) + "\n"
+	}
+	if r, n := recentHookFailure(m.hookRuns, time.Now()); n > 0 {
+		prompt += renderHookFailure(r, n, m.width, m.styles) + "\n"
+	}
+	if m.configErr != nil {
+		prompt += renderWarning(m.configErr.Error()+" (using default settings)", m.width, m.styles) + "\n"
+	}
+	if m.decisionsErr != nil {
+		prompt += renderWarning("security decisions: "+m.decisionsErr.Error(), m.width, m.styles) + "\n"
+	}
+	for _, e := range m.sinkErrors {
+		prompt += renderWarning(e, m.width, m.styles) + "\n"
+	}
+	if m.err != nil {
+		prompt += renderWarning(m.err.Error(), m.width, m.styles) + "\n"
+	}
+	return prompt
+}
+
+// pageView is the scrollable view on screen, falling back to the
+// dashboard when the detail view's agent is gone
+func (m Model) pageView() View {
+	if m.currentView == ViewDetail && m.selected >= 0 && m.selected < len(m.agents) {
+		return ViewDetail
+	}
+	return ViewDashboard
+}
+
+// page renders the full content of a scrollable view
+func (m Model) page(view View) *page {
+	opts := m.pageOpts[view]
+	if view == ViewDetail {
+		a := m.agents[m.selected]
+		return RenderDetail(a, a.FileOps, m.alerts, m.secEvents, m.trends.samples(a.PID), m.trends.window, opts, m.width, m.styles, m.config.Display)
+	}
+	opts.layout = m.layout
+	opts.sort = m.sort
+	opts.filter = m.filter
+	opts.total = len(m.scanned)
+	opts.groups = m.groups
+	return RenderDashboard(m.agents, m.selected, m.alerts, m.secEvents, m.localModels, m.budgets, m.timeline, opts, m.width, m.styles, m.config.Display)
+}
+
+// bodyHeight is the rows left for a page below the banners and above
+// the one-line help bar
+func (m Model) bodyHeight(prompt string) int {
+	return max(m.height-strings.Count(prompt, "\n")-lipgloss.Height(m.styles.Help.Render("")), 1)
+}
+
+// scrollBy scrolls the current page by delta lines
+func (m *Model) scrollBy(delta int) {
+	if m.currentView != ViewDashboard && m.currentView != ViewDetail {
+		return
+	}
+	view := m.pageView()
+	p := m.page(view)
+	m.viewports[view] = m.viewports[view].scroll(delta, p.Lines(), m.bodyHeight(m.prompt()))
+}
 
-			// Record history
-			m.history.Record(m.agents)
+// showSelected scrolls the dashboard so the selected agent card is visible
+func (m *Model) showSelected() {
+	p := m.page(ViewDashboard)
+	if s, ok := p.items[m.selected]; ok {
+		m.viewports[ViewDashboard] = m.viewports[ViewDashboard].show(s, p.Lines(), m.bodyHeight(m.prompt()))
+	}
+}
 
-			// Collect local model server info
-			if m.config.LocalModels.Enabled {
-				m.localModels = m.localModelMon.Collect()
+// handlePageKey handles scrolling, section focus and expansion on the
+// dashboard and detail pages and reports whether it consumed the key
+func (m *Model) handlePageKey(key string) bool {
+	view := m.pageView()
+	height := m.bodyHeight(m.prompt())
+	opts := m.pageOpts[view]
+	p := m.page(view)
+	vp := m.viewports[view]
+
+	switch key {
+	case "pgup":
+		vp = vp.scroll(-height, p.Lines(), height)
+	case "pgdown":
+		vp = vp.scroll(height, p.Lines(), height)
+	case "home":
+		vp.offset = 0
+	case "end":
+		vp = vp.scroll(p.Lines(), p.Lines(), height)
+	case "[", "]":
+		step := 1
+		if key == "[" {
+			step = -1
+		}
+		opts.focus = p.nextSection(opts.focus, step)
+		if opts.focus == "" {
+			return true
+		}
+		m.pageOpts[view] = opts
+		// Focus changes the title, so measure the re-rendered page
+		p = m.page(view)
+		vp.offset = p.starts[opts.focus]
+		vp = vp.clamp(p.Lines(), height)
+	case "a":
+		// Copy so the change doesn't leak into earlier model values
+		expanded := maps.Clone(opts.expanded)
+		if expanded == nil {
+			expanded = map[string]bool{}
+		}
+		if opts.focus != "" {
+			expanded[opts.focus] = !expanded[opts.focus]
+		} else {
+			// Without a focused section, expand everything unless it
+			// already is, in which case collapse everything
+			all := !slices.ContainsFunc(p.sections, func(name string) bool { return !expanded[name] })
+			for _, name := range p.sections {
+				expanded[name] = !all
 			}
 		}
-		return m, nil
+		opts.expanded = expanded
+		m.pageOpts[view] = opts
+	default:
+		return false
 	}
 
-	return m, nil
+	m.viewports[view] = vp
+	return true
 }
 
-// View renders the UI
-func (m Model) View() string {
-	if m.width == 0 {
-		return "Loading..."
+// pendingAction returns the oldest process still paused by enforcement
+func (m Model) pendingAction() (enforce.Action, bool) {
+	for _, a := range m.enforcement {
+		if a.Status == enforce.StatusPaused {
+			return a, true
+		}
 	}
+	return enforce.Action{}, false
+}
 
-	switch m.currentView {
-	case ViewDetail:
-		if m.selected >= 0 && m.selected < len(m.agents) {
-			a := m.agents[m.selected]
-			return RenderDetail(a, a.FileOps, m.alerts, m.secEvents, m.width, m.height, m.styles, m.config.Display)
+// resolver is whoever paused the process: the daemon or the local collector
+func (m Model) resolver() collector.Resolver {
+	if r, ok := m.remote.(collector.Resolver); ok {
+		return r
+	}
+	return m.collector
+}
+
+// resolveAction resumes or terminates a paused process, then refreshes
+func (m Model) resolveAction(pid int, terminate bool) tea.Cmd {
+	r := m.resolver()
+	return func() tea.Msg {
+		if terminate {
+			return resolvedMsg{err: r.Terminate(pid)}
 		}
-		m.currentView = ViewDashboard
-		return RenderDashboard(m.agents, m.selected, m.alerts, m.secEvents, m.localModels, m.width, m.height, m.styles, m.config.Display)
-	default:
-		return RenderDashboard(m.agents, m.selected, m.alerts, m.secEvents, m.localModels, m.width, m.height, m.styles, m.config.Display)
+		return resolvedMsg{err: r.Resume(pid)}
 	}
 }
 
@@ -243,29 +442,111 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	key := msg.String()
 	kb := m.config.Keybindings
 
+	if m.filter.editing && m.handleFilterKey(msg) {
+		return m, nil
+	}
+	if m.currentView == ViewSecurity && m.handleSecurityKey(key) {
+		return m, nil
+	}
+	if (m.currentView == ViewDashboard || m.currentView == ViewDetail) && m.handlePageKey(key) {
+		return m, nil
+	}
+
 	switch {
+	case key == "c" || key == "x":
+		if a, ok := m.pendingAction(); ok {
+			return m, m.resolveAction(a.PID, key == "x")
+		}
+
 	case key == kb.Quit || key == "ctrl+c":
-		m.fileMon.Stop()
 		return m, tea.Quit
 
 	case key == kb.Up || key == "k":
-		if m.currentView == ViewDashboard && m.selected > 0 {
-			m.selected--
+		if m.currentView == ViewDetail {
+			m.scrollBy(-1)
+		} else if m.currentView == ViewDashboard && m.selected > 0 {
+			m.selectAt(m.selected - 1)
+			m.showSelected()
 		}
 
 	case key == kb.Down || key == "j":
-		if m.currentView == ViewDashboard && m.selected < len(m.agents)-1 {
-			m.selected++
+		if m.currentView == ViewDetail {
+			m.scrollBy(1)
+		} else if m.currentView == ViewDashboard && m.selected < len(m.agents)-1 {
+			m.selectAt(m.selected + 1)
+			m.showSelected()
 		}
 
 	case key == kb.Detail:
 		if m.currentView == ViewDashboard && len(m.agents) > 0 {
-			m.currentView = ViewDetail
+			m.openDetail()
 		}
 
 	case key == kb.Back:
-		if m.currentView == ViewDetail {
+		if m.currentView == ViewDetail || m.currentView == ViewSecurity || m.currentView == ViewTimeline {
 			m.currentView = ViewDashboard
+		} else if m.filter.query != "" {
+			m.filter = agentFilter{}
+			m.refreshList()
+			m.showSelected()
+		}
+
+	case key == "/":
+		if m.currentView == ViewDashboard {
+			m.filter.editing = true
+		}
+
+	case key == "g":
+		if m.currentView == ViewDashboard {
+			m.grouped = !m.grouped
+			m.refreshList()
+			m.showSelected()
+		}
+
+	case key == "o":
+		if m.currentView == ViewDashboard {
+			m.sort = m.sort.cycle()
+			m.refreshList()
+			m.showSelected()
+		}
+
+	case key == "s":
+		if m.currentView == ViewDashboard {
+			m.currentView = ViewSecurity
+			m.secSelected = 0
+		}
+
+	case key == "t":
+		if m.currentView == ViewDashboard {
+			m.currentView = ViewTimeline
+		}
+
+	case key == "v":
+		if m.currentView == ViewDashboard {
+			if m.layout == LayoutTable {
+				m.layout = LayoutCards
+			} else {
+				m.layout = LayoutTable
+			}
+			m.showSelected()
+		}
+
+	case key == "<" || key == ">":
+		if m.currentView == ViewDashboard && m.layout == LayoutTable {
+			step := 1
+			if key == "<" {
+				step = -1
+			}
+			m.sort = m.sort.next(visibleColumns(m.width, m.config.Display), step)
+			m.refreshList()
+			m.showSelected()
+		}
+
+	case key == "-":
+		if m.currentView == ViewDashboard && m.sort.key != "" {
+			m.sort.desc = !m.sort.desc
+			m.refreshList()
+			m.showSelected()
 		}
 
 	case key == kb.Refresh:
@@ -273,14 +554,12 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 
 	case key == kb.Export:
 		// Export current history
-		if err := m.history.ExportJSON(""); err == nil {
-			// Silently exported
-		}
+		m.err = m.history.ExportJSON("")
 		return m, nil
 
 	case key == kb.Toggle:
 		if m.currentView == ViewDashboard {
-			m.currentView = ViewDetail
+			m.openDetail()
 		} else {
 			m.currentView = ViewDashboard
 		}
@@ -289,10 +568,158 @@ func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// refreshList rebuilds the displayed agents from the last scan with the
+// current filter and sort. The selection follows its agent's PID, so a
+// rescan or reorder doesn't move it to another agent.
+func (m *Model) refreshList() {
+	m.agents = m.sort.apply(m.filter.apply(m.scanned))
+	m.groups = nil
+	if m.grouped {
+		// Keep the sort within each group
+		m.groups = m.roots.Build(m.agents)
+		rank := map[int]int{}
+		for i, g := range m.groups {
+			for _, pid := range g.PIDs {
+				rank[pid] = i
+			}
+		}
+		m.agents = slices.Clone(m.agents)
+		slices.SortStableFunc(m.agents, func(a, b agent.Instance) int {
+			return cmp.Compare(rank[a.PID], rank[b.PID])
+		})
+	}
+
+	i := slices.IndexFunc(m.agents, func(a agent.Instance) bool { return a.PID == m.selectedPID })
+	if i < 0 {
+		// The agent exited or was filtered out
+		if m.currentView == ViewDetail {
+			m.currentView = ViewDashboard
+		}
+		i = min(m.selected, len(m.agents)-1)
+	}
+	m.selectAt(max(i, 0))
+}
+
+// selectAt selects the agent at index i of the displayed list
+func (m *Model) selectAt(i int) {
+	m.selected = i
+	m.selectedPID = 0
+	if i < len(m.agents) {
+		m.selectedPID = m.agents[i].PID
+	}
+}
+
+// handleFilterKey edits the / filter query and reports whether it
+// consumed the key; the list narrows as it is typed. Enter keeps the
+// filter, Esc clears it, and other keys (arrows, ctrl+c) work as usual.
+func (m *Model) handleFilterKey(msg tea.KeyMsg) bool {
+	switch msg.Type {
+	case tea.KeyEnter:
+		m.filter.editing = false
+	case tea.KeyEsc:
+		m.filter = agentFilter{}
+	case tea.KeyBackspace:
+		if r := []rune(m.filter.query); len(r) > 0 {
+			m.filter.query = string(r[:len(r)-1])
+		}
+	case tea.KeySpace:
+		m.filter.query += " "
+	case tea.KeyRunes:
+		m.filter.query += string(msg.Runes)
+	default:
+		return false
+	}
+	m.refreshList()
+	m.showSelected()
+	return true
+}
+
+// showAgent opens the detail view of the agent with the given name or ID,
+// clearing a filter that hides it
+func (m *Model) showAgent(name string) {
+	is := func(a agent.Instance) bool { return a.Info.Name == name || a.Info.ID == name }
+	i := slices.IndexFunc(m.scanned, is)
+	if i < 0 {
+		// The agent exited since the notification
+		return
+	}
+	if !slices.ContainsFunc(m.agents, is) {
+		m.filter = agentFilter{}
+	}
+	m.selectedPID = m.scanned[i].PID
+	m.refreshList()
+	m.openDetail()
+}
+
+// openDetail switches to the detail view, starting at its top
+func (m *Model) openDetail() {
+	m.currentView = ViewDetail
+	m.viewports[ViewDetail] = viewport{}
+	m.pageOpts[ViewDetail] = pageOptions{}
+}
+
+// handleSecurityKey handles keys owned by the security view and reports
+// whether it consumed the key
+func (m *Model) handleSecurityKey(key string) bool {
+	kb := m.config.Keybindings
+	events := m.secFilter.apply(m.secLog, m.decisions)
+
+	switch key {
+	case kb.Up, "k":
+		if m.secSelected > 0 {
+			m.secSelected--
+		}
+	case kb.Down, "j":
+		if m.secSelected < len(events)-1 {
+			m.secSelected++
+		}
+	case "a", "f", "u":
+		if m.secSelected >= len(events) {
+			return true
+		}
+		evt := events[m.secSelected]
+		var err error
+		switch key {
+		case "a":
+			err = m.decisions.Decide(evt, review.Acknowledged, time.Now())
+		case "f":
+			err = m.decisions.Decide(evt, review.FalsePositive, time.Now())
+		default:
+			err = m.decisions.Undo(evt)
+		}
+		m.err = err
+		m.secEvents = m.decisions.Pending(m.secEvents)
+	case "1":
+		m.secFilter.severity = nextValue(severityCycle, m.secFilter.severity)
+	case "2":
+		categories := distinct(m.secLog, func(e agent.SecurityEvent) agent.SecurityCategory { return e.Category })
+		m.secFilter.category = nextValue(categories, m.secFilter.category)
+	case "3":
+		names := distinct(m.secLog, func(e agent.SecurityEvent) string { return e.AgentName })
+		m.secFilter.agentName = nextValue(names, m.secFilter.agentName)
+	case "h":
+		m.secFilter.showDecided = !m.secFilter.showDecided
+	default:
+		return false
+	}
+
+	// Filters and decisions change the list under the cursor
+	if n := len(m.secFilter.apply(m.secLog, m.decisions)); m.secSelected >= n {
+		m.secSelected = max(n-1, 0)
+	}
+	return true
+}
+
 // scanAgents performs an async agent scan
 func (m Model) scanAgents() tea.Cmd {
+	if m.remote != nil {
+		return func() tea.Msg {
+			st, err := m.remote.State()
+			return stateMsg{state: st, err: err}
+		}
+	}
 	return func() tea.Msg {
-		agents, err := m.detector.Scan()
+		agents, err := m.collector.Scan()
 		return agentScanMsg{agents: agents, err: err}
 	}
 }
@@ -308,12 +735,21 @@ func (m Model) tick() tea.Cmd {
 	})
 }
 
-// StartApp starts the TUI application
-func StartApp(cfg *config.Config) error {
-	model := NewModel(cfg)
-
-	// Start file watcher
-	model.fileMon.Start(1 * time.Second)
+// StartApp starts the TUI application. Sinks are only attached when the
+// TUI collects locally; an attached daemon publishes on its own. A
+// non-nil configErr is shown as a warning banner.
+func StartApp(cfg *config.Config, appCfg *appconfig.Config, remote collector.Source, sinks []collector.Sink, configErr error) error {
+	model := NewModel(cfg, appCfg, remote)
+	model.configErr = configErr
+
+	// Start file watcher (only needed when collecting locally)
+	if remote == nil {
+		for _, sink := range sinks {
+			model.collector.AddSink(sink)
+		}
+		model.collector.Start()
+		defer model.collector.Stop()
+	}
 
 	p := tea.NewProgram(
 		model,
@@ -321,6 +757,26 @@ func StartApp(cfg *config.Config) error {
 		tea.WithMouseCellMotion(),
 	)
 
+	// Clicking a desktop notification brings this TUI forward on the agent
+	for _, sink := range sinks {
+		if c, ok := sink.(interface{ OnClick(func(string)) }); ok {
+			c.OnClick(func(agent string) {
+				focusTmuxPane()
+				p.Send(notificationClickMsg{agent: agent})
+			})
+		}
+	}
+
 	_, err := p.Run()
 	return err
 }
+
+// focusTmuxPane selects the TUI's window and pane when it runs in tmux;
+// outside tmux the terminal emulator has to be raised by on_click
+func focusTmuxPane() {
+	pane := os.Getenv("TMUX_PANE")
+	if pane == "" {
+		return
+	}
+	_ = exec.Command("tmux", "select-window", "-t", pane, ";", "select-pane", "-t", pane).Run()
+}
@@ -11,11 +11,74 @@ import (
 	"github.com/Rafiki81/libagentmetrics/config"
 	"github.com/Rafiki81/libagentmetrics/monitor"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/rafaelperezbeato/agentmetrics/internal/budget"
+	"github.com/rafaelperezbeato/agentmetrics/internal/enforce"
+	"github.com/rafaelperezbeato/agentmetrics/internal/groups"
+	"github.com/rafaelperezbeato/agentmetrics/internal/hooks"
+	"github.com/rafaelperezbeato/agentmetrics/internal/lifecycle"
 )
 
-// RenderDashboard renders the main dashboard view
-func RenderDashboard(agents []agent.Instance, selected int, alerts []agent.Alert, secEvents []agent.SecurityEvent, localModels []agent.LocalModelInfo, width, height int, s *Styles, disp config.DisplayConfig) string {
-	var b strings.Builder
+// pageOptions is the view state a scrollable page renders with
+type pageOptions struct {
+	// focus is the highlighted section
+	focus string
+	// expanded sections list every entry instead of the latest few
+	expanded map[string]bool
+	// layout is the dashboard's agent list layout, cards or table
+	layout string
+	// sort is the agent list's order, marked in the table header
+	sort agentSort
+	// filter narrows the agent list, out of total scanned agents
+	filter agentFilter
+	total  int
+	// groups clusters the agent list by repository; nil lists it flat
+	groups []groups.Group
+}
+
+// title prefixes the focused section's title with a marker
+func (o pageOptions) title(section, title string) string {
+	if o.focus == section {
+		return "▸ " + title
+	}
+	return title
+}
+
+// from returns the first of n entries to show when only the last limit
+// fit, unless the section is expanded
+func (o pageOptions) from(section string, n, limit int) int {
+	if o.expanded[section] || n <= limit {
+		return 0
+	}
+	return n - limit
+}
+
+// listStatus describes the agent list's sort and filter; "" when the
+// list is in scan order and unfiltered
+func listStatus(opts pageOptions, shown int) string {
+	var parts []string
+	if label := opts.sort.label(); label != "" {
+		parts = append(parts, "Sort: "+label)
+	}
+	if opts.filter.query != "" {
+		parts = append(parts, fmt.Sprintf("Filter: %q (%d of %d)", opts.filter.query, shown, opts.total))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " " + strings.Join(parts, "  │  ")
+}
+
+// moreLine hints at entries hidden by a cap
+func moreLine(hidden int, s *Styles) string {
+	return lipgloss.NewStyle().Foreground(s.Theme.Muted).Italic(true).
+		Render(fmt.Sprintf("  … %d earlier, [ ] focus + a show all", hidden)) + "\n"
+}
+
+// RenderDashboard renders the main dashboard as a scrollable page. The
+// help bar is drawn separately so it stays on screen.
+func RenderDashboard(agents []agent.Instance, selected int, alerts []agent.Alert, secEvents []agent.SecurityEvent, localModels []agent.LocalModelInfo, budgets []budget.Usage, timeline []lifecycle.Event, opts pageOptions, width int, s *Styles, disp config.DisplayConfig) *page {
+	b := newPage()
 
 	// Header
 	logo := s.Logo.Render("◈ AgentMetrics")
@@ -31,12 +94,10 @@ func RenderDashboard(agents []agent.Instance, selected int, alerts []agent.Alert
 	b.WriteString(headerLine + headerPadding + timestamp + "\n")
 	b.WriteString(lipgloss.NewStyle().Foreground(s.Theme.Border).Render(strings.Repeat("─", width)) + "\n")
 
-	if len(agents) == 0 {
+	if opts.total == 0 {
 		msg := s.Empty.Width(width).Render("\n🔍 Scanning for AI agents...\n\nNo active agents detected.\nStart an AI agent (claude, codex, aider, etc.) to monitor it.\n")
 		b.WriteString(msg)
-		b.WriteString("\n")
-		b.WriteString(renderHelp(width, s))
-		return b.String()
+		return b
 	}
 
 	// Summary bar
@@ -98,27 +159,30 @@ func RenderDashboard(agents []agent.Instance, selected int, alerts []agent.Alert
 	}
 
 	b.WriteString(lipgloss.NewStyle().Foreground(s.Theme.Fg).Render(summary) + "\n")
+	if line := listStatus(opts, len(agents)); line != "" {
+		b.WriteString(lipgloss.NewStyle().Foreground(s.Theme.Muted).Render(line) + "\n")
+	}
+	if len(budgets) > 0 {
+		b.WriteString(renderBudgets(budgets, s) + "\n")
+	}
 	b.WriteString(lipgloss.NewStyle().Foreground(s.Theme.Border).Render(strings.Repeat("─", width)) + "\n\n")
 
 	// Agent list
-	cardWidth := width - 4
-	if cardWidth < 40 {
-		cardWidth = 40
-	}
-
-	for i, a := range agents {
-		card := renderAgentCard(a, cardWidth, i == selected, s, disp)
-		b.WriteString(card + "\n")
+	b.section("agents")
+	if len(agents) == 0 {
+		b.WriteString(s.Empty.Width(width).Render(fmt.Sprintf("No agents match %q (Esc clears the filter)", opts.filter.query)) + "\n")
+	} else {
+		renderAgentList(b, agents, selected, opts, width, s, disp)
 	}
 
 	// Recent alerts section
 	if len(alerts) > 0 && disp.ShowAlerts {
 		b.WriteString(lipgloss.NewStyle().Foreground(s.Theme.Border).Render(strings.Repeat("─", width)) + "\n")
-		b.WriteString(s.Header.Width(width).Render("⚡ Recent Alerts") + "\n")
-		maxShow := 3
-		start := 0
-		if len(alerts) > maxShow {
-			start = len(alerts) - maxShow
+		b.section("alerts")
+		b.WriteString(s.Header.Width(width).Render(opts.title("alerts", "⚡ Recent Alerts")) + "\n")
+		start := opts.from("alerts", len(alerts), 3)
+		if start > 0 {
+			b.WriteString(moreLine(start, s))
 		}
 		for _, al := range alerts[start:] {
 			icon := "ℹ"
@@ -144,11 +208,11 @@ func RenderDashboard(agents []agent.Instance, selected int, alerts []agent.Alert
 	// Recent security events
 	if len(secEvents) > 0 && disp.ShowSecurity {
 		b.WriteString(lipgloss.NewStyle().Foreground(s.Theme.Border).Render(strings.Repeat("─", width)) + "\n")
-		b.WriteString(s.SecurityBanner.Width(width).Render("🛡 Security Events") + "\n")
-		maxShow := 5
-		start := 0
-		if len(secEvents) > maxShow {
-			start = len(secEvents) - maxShow
+		b.section("security")
+		b.WriteString(s.SecurityBanner.Width(width).Render(opts.title("security", "🛡 Security Events")) + "\n")
+		start := opts.from("security", len(secEvents), 5)
+		if start > 0 {
+			b.WriteString(moreLine(start, s))
 		}
 		for _, evt := range secEvents[start:] {
 			icon, style := securitySeverityStyle(evt.Severity, s)
@@ -164,14 +228,22 @@ func RenderDashboard(agents []agent.Instance, selected int, alerts []agent.Alert
 		b.WriteString("\n")
 	}
 
+	// Agent transitions
+	if len(timeline) > 0 {
+		b.WriteString(lipgloss.NewStyle().Foreground(s.Theme.Border).Render(strings.Repeat("─", width)) + "\n")
+		b.section("timeline")
+		b.WriteString(renderTimelinePanel(timeline, opts, width, s))
+	}
+
 	// Local models section
 	if len(localModels) > 0 && disp.ShowLocalModels {
 		b.WriteString(lipgloss.NewStyle().Foreground(s.Theme.Border).Render(strings.Repeat("─", width)) + "\n")
+		b.section("models")
 		b.WriteString(lipgloss.NewStyle().
 			Bold(true).
 			Foreground(s.Theme.Secondary).
 			Width(width).
-			Render("🖥 Local Models") + "\n")
+			Render(opts.title("models", "🖥 Local Models")) + "\n")
 
 		for _, srv := range localModels {
 			statusColor := s.Theme.Success
@@ -229,6 +301,9 @@ func RenderDashboard(agents []agent.Instance, selected int, alerts []agent.Alert
 					modelNames = append(modelNames, name)
 				}
 				maxModels := 5
+				if opts.expanded["models"] {
+					maxModels = len(modelNames)
+				}
 				for i, name := range modelNames {
 					if i >= maxModels {
 						remaining := len(modelNames) - maxModels
@@ -244,10 +319,159 @@ func RenderDashboard(agents []agent.Instance, selected int, alerts []agent.Alert
 		b.WriteString("\n")
 	}
 
-	// Help bar
-	b.WriteString(renderHelp(width, s))
+	return b
+}
+
+// renderAgentList writes the agents as cards or table rows, each one an
+// item of the page. Grouped agents arrive ordered by group and get a
+// header whenever the group changes.
+func renderAgentList(b *page, agents []agent.Instance, selected int, opts pageOptions, width int, s *Styles, disp config.DisplayConfig) {
+	var cols []tableColumn
+	if opts.layout == LayoutTable {
+		cols = visibleColumns(width, disp)
+		b.WriteString(renderTableHeader(cols, opts.sort, s) + "\n")
+	}
+	cardWidth := width - 4
+	if cardWidth < 40 {
+		cardWidth = 40
+	}
+
+	groupOf := map[int]int{}
+	for i, g := range opts.groups {
+		for _, pid := range g.PIDs {
+			groupOf[pid] = i
+		}
+	}
+	current := -1
+	for i, a := range agents {
+		if g, ok := groupOf[a.PID]; ok && g != current {
+			current = g
+			b.WriteString(renderGroupHeader(opts.groups[g], width, s, disp) + "\n")
+		}
+		if cols != nil {
+			b.item(i, renderTableRow(a, cols, i == selected, s))
+		} else {
+			b.item(i, renderAgentCard(a, cardWidth, i == selected, s, disp))
+		}
+	}
+	if cols != nil {
+		b.WriteString("\n")
+	}
+}
+
+// renderGroupHeader renders a repository's name, root and totals
+func renderGroupHeader(g groups.Group, width int, s *Styles, disp config.DisplayConfig) string {
+	icon := "⎇"
+	if !g.Repo {
+		icon = "📂"
+	}
+	agents := "1 agent"
+	if g.Agents != 1 {
+		agents = fmt.Sprintf("%d agents", g.Agents)
+	}
+	parts := []string{s.MetricValue.Render(agents)}
+	if disp.ShowTokens {
+		parts = append(parts, s.TokenLabel.Render("◆ ")+s.TokenValue.Render(monitor.FormatTokenCount(g.TotalTokens)))
+	}
+	if disp.ShowCost {
+		parts = append(parts, s.Cost.Render(monitor.FormatCost(g.Cost)))
+	}
+	if disp.ShowGit {
+		parts = append(parts, lipgloss.NewStyle().Foreground(lipgloss.Color("#22D3EE")).Render(fmt.Sprintf("✎ +%d/-%d", g.LOCAdded, g.LOCRemoved)))
+		if g.Uncommitted > 0 {
+			parts = append(parts, s.Git.Render(fmt.Sprintf("%d uncommitted", g.Uncommitted)))
+		}
+	}
+
+	header := lipgloss.NewStyle().Bold(true).Foreground(s.Theme.Primary).Render(icon+" "+g.Name) + "  " +
+		lipgloss.NewStyle().Foreground(s.Theme.Muted).Render(shortenPath(g.Root)) + "  " +
+		strings.Join(parts, "  │  ")
+	return " " + ansi.Truncate(header, max(width-1, 0), "…")
+}
+
+// renderBudgets renders one progress bar per budget, for whichever of its
+// caps is closest to the limit
+func renderBudgets(usage []budget.Usage, s *Styles) string {
+	var order []string
+	worst := map[string]budget.Usage{}
+	for _, u := range usage {
+		prev, ok := worst[u.Budget]
+		if !ok {
+			order = append(order, u.Budget)
+		}
+		if !ok || u.Fraction() > prev.Fraction() {
+			worst[u.Budget] = u
+		}
+	}
+
+	parts := make([]string, 0, len(order))
+	for _, name := range order {
+		u := worst[name]
+		style := s.Cost
+		if u.Exceeded() {
+			style = s.AlertCrit
+		} else if u.Fraction() >= 0.8 {
+			style = s.AlertWarn
+		}
+		parts = append(parts, fmt.Sprintf("%s %s %s %s",
+			lipgloss.NewStyle().Foreground(s.Theme.Muted).Render(name+" ("+u.Period+")"),
+			s.RenderBar(u.Spent, u.Limit, 10),
+			style.Render(monitor.FormatCost(u.Spent)),
+			lipgloss.NewStyle().Foreground(s.Theme.Muted).Render("/ "+monitor.FormatCost(u.Limit)),
+		))
+	}
+	return " Budget: " + strings.Join(parts, "  │  ")
+}
+
+// renderEnforcementPrompt asks what to do with a paused process
+func renderEnforcementPrompt(a enforce.Action, width int, s *Styles) string {
+	cmd := a.Command
+	if len(cmd) > 60 {
+		cmd = cmd[:57] + "..."
+	}
+	line := fmt.Sprintf(" ⏸ Paused PID %d (%s) from %s: %s  │  c continue  │  x terminate",
+		a.PID, a.Reason, a.AgentName, cmd)
+	return s.AlertCrit.Width(width).Render(line)
+}
+
+// renderWarning shows a problem that needs fixing outside the TUI, such
+// as a broken config or a failing exporter, on one line
+func renderWarning(msg string, width int, s *Styles) string {
+	line := ansi.Truncate(" ⚠ "+strings.ReplaceAll(msg, "\n", " "), max(width, 1), "…")
+	return s.AlertWarn.Width(width).Render(line)
+}
+
+// hookFailureWindow is how long a failed hook stays on screen
+const hookFailureWindow = 10 * time.Minute
+
+// recentHookFailure returns the latest failed hook run and how many runs
+// failed within the window
+func recentHookFailure(runs []hooks.Result, now time.Time) (hooks.Result, int) {
+	var latest hooks.Result
+	n := 0
+	for _, r := range runs {
+		if r.Failed() && now.Sub(r.Started) < hookFailureWindow {
+			latest = r
+			n++
+		}
+	}
+	return latest, n
+}
 
-	return b.String()
+// renderHookFailure shows the latest failed hook with its output
+func renderHookFailure(r hooks.Result, n int, width int, s *Styles) string {
+	out := strings.ReplaceAll(r.Output, "\n", " ")
+	if len(out) > 60 {
+		out = out[:57] + "..."
+	}
+	line := fmt.Sprintf(" ⚠ Hook %q failed on %s at %s: %s", r.Hook, r.Event, r.Started.Format("15:04:05"), r.Error)
+	if out != "" {
+		line += "  │  " + out
+	}
+	if n > 1 {
+		line += fmt.Sprintf("  (+%d more)", n-1)
+	}
+	return s.AlertWarn.Width(width).Render(line)
 }
 
 // renderAgentCard renders a single agent card
@@ -370,15 +594,16 @@ func renderAgentCard(a agent.Instance, width int, selected bool, s *Styles, disp
 	return style.Render(content)
 }
 
-// RenderDetail renders the agent detail panel
-func RenderDetail(a agent.Instance, fileOps []agent.FileOperation, alerts []agent.Alert, secEvents []agent.SecurityEvent, width, height int, s *Styles, disp config.DisplayConfig) string {
-	var b strings.Builder
+// RenderDetail renders the agent detail panel as a scrollable page
+func RenderDetail(a agent.Instance, fileOps []agent.FileOperation, alerts []agent.Alert, secEvents []agent.SecurityEvent, trend []trendSample, window time.Duration, opts pageOptions, width int, s *Styles, disp config.DisplayConfig) *page {
+	b := newPage()
 
 	// Header
 	b.WriteString(s.Header.Width(width).Render(fmt.Sprintf("◈ %s — Details", a.Info.Name)))
 	b.WriteString("\n")
 
 	// Info section
+	b.section("info")
 	infoPanel := s.DetailPanel.Width(width - 4)
 
 	info := fmt.Sprintf(
@@ -399,9 +624,19 @@ func RenderDetail(a agent.Instance, fileOps []agent.FileOperation, alerts []agen
 	b.WriteString(infoPanel.Render(info))
 	b.WriteString("\n\n")
 
+	// Trends over the rolling window
+	if len(trend) > 1 {
+		b.section("trends")
+		b.WriteString(s.Header.Width(width).Render(opts.title("trends", "📈 Trends")))
+		b.WriteString("\n")
+		b.WriteString(s.DetailPanel.Width(width - 4).Render(renderTrends(trend, window, width, s)))
+		b.WriteString("\n\n")
+	}
+
 	// Token metrics section
 	if disp.ShowTokens {
-		b.WriteString(s.Header.Width(width).Render("◆ Token Metrics"))
+		b.section("tokens")
+		b.WriteString(s.Header.Width(width).Render(opts.title("tokens", "◆ Token Metrics")))
 		b.WriteString("\n")
 		tokenPanel := s.DetailPanel.Width(width - 4)
 
@@ -441,7 +676,8 @@ func RenderDetail(a agent.Instance, fileOps []agent.FileOperation, alerts []agen
 
 	// Session metrics section
 	if disp.ShowSession && a.Session.Uptime > 0 {
-		b.WriteString(s.Header.Width(width).Render("⏱ Session"))
+		b.section("session")
+		b.WriteString(s.Header.Width(width).Render(opts.title("session", "⏱ Session")))
 		b.WriteString("\n")
 		sessPanel := s.DetailPanel.Width(width - 4)
 		sessInfo := fmt.Sprintf(
@@ -463,7 +699,8 @@ func RenderDetail(a agent.Instance, fileOps []agent.FileOperation, alerts []agen
 
 	// Git activity section
 	if disp.ShowGit && a.Git.Branch != "" {
-		b.WriteString(s.Header.Width(width).Render("⎇ Git Activity"))
+		b.section("git")
+		b.WriteString(s.Header.Width(width).Render(opts.title("git", "⎇ Git Activity")))
 		b.WriteString("\n")
 		gitPanel := s.DetailPanel.Width(width - 4)
 
@@ -490,7 +727,7 @@ func RenderDetail(a agent.Instance, fileOps []agent.FileOperation, alerts []agen
 			gitLines = append(gitLines, "")
 			gitLines = append(gitLines, s.Git.Render("Recent commits:"))
 			maxCommits := 5
-			if len(a.Git.RecentCommits) < maxCommits {
+			if opts.expanded["git"] || len(a.Git.RecentCommits) < maxCommits {
 				maxCommits = len(a.Git.RecentCommits)
 			}
 			for _, c := range a.Git.RecentCommits[:maxCommits] {
@@ -511,7 +748,8 @@ func RenderDetail(a agent.Instance, fileOps []agent.FileOperation, alerts []agen
 
 	// Terminal commands section
 	if disp.ShowTerminal && a.Terminal.TotalCommands > 0 {
-		b.WriteString(s.Header.Width(width).Render("⌨ Terminal Commands"))
+		b.section("terminal")
+		b.WriteString(s.Header.Width(width).Render(opts.title("terminal", "⌨ Terminal Commands")))
 		b.WriteString("\n")
 		termPanel := s.DetailPanel.Width(width - 4)
 
@@ -523,10 +761,9 @@ func RenderDetail(a agent.Instance, fileOps []agent.FileOperation, alerts []agen
 
 		if len(a.Terminal.RecentCommands) > 0 {
 			termLines = append(termLines, "")
-			maxCmds := 10
-			start := 0
-			if len(a.Terminal.RecentCommands) > maxCmds {
-				start = len(a.Terminal.RecentCommands) - maxCmds
+			start := opts.from("terminal", len(a.Terminal.RecentCommands), 10)
+			if start > 0 {
+				termLines = append(termLines, strings.TrimSuffix(moreLine(start, s), "\n"))
 			}
 			for _, cmd := range a.Terminal.RecentCommands[start:] {
 				cmdStr := cmd.Command
@@ -559,7 +796,8 @@ func RenderDetail(a agent.Instance, fileOps []agent.FileOperation, alerts []agen
 
 	// Network connections
 	if disp.ShowNetwork && len(a.NetConns) > 0 {
-		b.WriteString(s.Header.Width(width).Render("🌐 Network Connections"))
+		b.section("network")
+		b.WriteString(s.Header.Width(width).Render(opts.title("network", "🌐 Network Connections")))
 		b.WriteString("\n")
 		for _, conn := range a.NetConns {
 			desc := monitor.DescribeConnection(conn)
@@ -570,13 +808,13 @@ func RenderDetail(a agent.Instance, fileOps []agent.FileOperation, alerts []agen
 
 	// File operations
 	if disp.ShowFiles && len(fileOps) > 0 {
-		b.WriteString(s.Header.Width(width).Render("📄 Recent File Operations"))
+		b.section("files")
+		b.WriteString(s.Header.Width(width).Render(opts.title("files", "📄 Recent File Operations")))
 		b.WriteString("\n")
 
-		maxOps := 10
-		start := 0
-		if len(fileOps) > maxOps {
-			start = len(fileOps) - maxOps
+		start := opts.from("files", len(fileOps), 10)
+		if start > 0 {
+			b.WriteString(moreLine(start, s))
 		}
 
 		for _, op := range fileOps[start:] {
@@ -597,12 +835,12 @@ func RenderDetail(a agent.Instance, fileOps []agent.FileOperation, alerts []agen
 	if disp.ShowAlerts {
 		agentAlerts := filterAlertsByAgent(alerts, a.Info.ID)
 		if len(agentAlerts) > 0 {
-			b.WriteString(s.Header.Width(width).Render("⚡ Alerts"))
+			b.section("alerts")
+			b.WriteString(s.Header.Width(width).Render(opts.title("alerts", "⚡ Alerts")))
 			b.WriteString("\n")
-			maxAlerts := 5
-			start := 0
-			if len(agentAlerts) > maxAlerts {
-				start = len(agentAlerts) - maxAlerts
+			start := opts.from("alerts", len(agentAlerts), 5)
+			if start > 0 {
+				b.WriteString(moreLine(start, s))
 			}
 			for _, al := range agentAlerts[start:] {
 				icon := "ℹ"
@@ -629,12 +867,12 @@ func RenderDetail(a agent.Instance, fileOps []agent.FileOperation, alerts []agen
 	if disp.ShowSecurity {
 		agentSec := filterSecurityByAgent(secEvents, a.Info.ID)
 		if len(agentSec) > 0 {
-			b.WriteString(s.SecurityBanner.Width(width).Render("🛡 Security Events"))
+			b.section("security")
+			b.WriteString(s.SecurityBanner.Width(width).Render(opts.title("security", "🛡 Security Events")))
 			b.WriteString("\n")
-			maxEvts := 8
-			start := 0
-			if len(agentSec) > maxEvts {
-				start = len(agentSec) - maxEvts
+			start := opts.from("security", len(agentSec), 8)
+			if start > 0 {
+				b.WriteString(moreLine(start, s))
 			}
 			for _, evt := range agentSec[start:] {
 				icon, st := securitySeverityStyle(evt.Severity, s)
@@ -655,9 +893,7 @@ func RenderDetail(a agent.Instance, fileOps []agent.FileOperation, alerts []agen
 		}
 	}
 
-	b.WriteString(s.Help.Render("  ESC back  │  r refresh  │  e export  │  q quit"))
-
-	return b.String()
+	return b
 }
 
 // filterAlertsByAgent returns alerts for a specific agent
@@ -682,10 +918,37 @@ func formatLatency(ms int64) string {
 	return fmt.Sprintf("%dms", ms)
 }
 
-// renderHelp renders the help bar at the bottom
-func renderHelp(width int, s *Styles) string {
-	help := "  ↑/↓ navigate  │  Enter details  │  e export  │  r refresh  │  q quit"
-	return s.Help.Width(width).Render(help)
+// renderHelp renders the dashboard help bar, with the scroll position
+// when the page doesn't fit
+func renderHelp(width int, layout, position string, s *Styles) string {
+	help := "  ↑/↓ navigate  │  Enter details  │  / filter  │  o sort  │  - reverse  │  v table  │  g group  │  s security  │  t timeline  │  PgUp/PgDn scroll  │  [ ] section  │  a show all  │  e export  │  r refresh  │  q quit"
+	if layout == LayoutTable {
+		help = strings.Replace(help, "v table", "v cards  │  < > sort", 1)
+	}
+	return s.Help.Width(width).Render(fitHelp(help, position, width))
+}
+
+// renderDetailHelp renders the detail view help bar
+func renderDetailHelp(width int, position string, s *Styles) string {
+	help := "  ESC back  │  ↑/↓ PgUp/PgDn scroll  │  [ ] section  │  a show all  │  r refresh  │  e export  │  q quit"
+	return s.Help.Width(width).Render(fitHelp(help, position, width))
+}
+
+// renderFilterPrompt replaces the help bar while a filter is typed
+func renderFilterPrompt(f agentFilter, shown, total, width int, s *Styles) string {
+	prompt := lipgloss.NewStyle().Bold(true).Foreground(s.Theme.Primary).Render("  / "+f.query+"█") +
+		fmt.Sprintf("   %d of %d  │  Enter keep  │  Esc clear", shown, total)
+	return s.Help.Width(width).Render(prompt)
+}
+
+// fitHelp keeps a help line to one row, truncating the key list before
+// the viewport position
+func fitHelp(help, position string, width int) string {
+	suffix := ""
+	if position != "" {
+		suffix = "  │  ↕ " + position
+	}
+	return ansi.Truncate(help, max(width-ansi.StringWidth(suffix), 0), "…") + suffix
 }
 
 // shortenPath shortens a file path for display
@@ -0,0 +1,185 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/rafaelperezbeato/agentmetrics/internal/review"
+	"github.com/rafaelperezbeato/agentmetrics/internal/suppress"
+)
+
+// maxSecurityLog caps the events kept for the security view
+const maxSecurityLog = 500
+
+// severityCycle is the order the severity filter steps through
+var severityCycle = []agent.SecuritySeverity{"", agent.SecSevCritical, agent.SecSevHigh, agent.SecSevMedium, agent.SecSevLow}
+
+// securityFilter narrows the security view; empty fields match everything
+type securityFilter struct {
+	severity    agent.SecuritySeverity
+	category    agent.SecurityCategory
+	agentName   string
+	showDecided bool
+}
+
+// apply returns the matching events, newest first
+func (f securityFilter) apply(events []agent.SecurityEvent, decisions *review.Store) []agent.SecurityEvent {
+	var out []agent.SecurityEvent
+	for i := len(events) - 1; i >= 0; i-- {
+		evt := events[i]
+		if f.severity != "" && evt.Severity != f.severity {
+			continue
+		}
+		if f.category != "" && evt.Category != f.category {
+			continue
+		}
+		if f.agentName != "" && evt.AgentName != f.agentName {
+			continue
+		}
+		if _, decided := decisions.Status(evt); decided && !f.showDecided {
+			continue
+		}
+		out = append(out, evt)
+	}
+	return out
+}
+
+// mergeSecurityLog adds events not seen before. The monitor only returns
+// its most recent events, so the view keeps its own longer log.
+func mergeSecurityLog(log, events []agent.SecurityEvent) []agent.SecurityEvent {
+	seen := make(map[string]bool, len(log))
+	for _, evt := range log {
+		seen[securityLogKey(evt)] = true
+	}
+	for _, evt := range events {
+		if !seen[securityLogKey(evt)] {
+			seen[securityLogKey(evt)] = true
+			log = append(log, evt)
+		}
+	}
+	if len(log) > maxSecurityLog {
+		log = log[len(log)-maxSecurityLog:]
+	}
+	return log
+}
+
+func securityLogKey(evt agent.SecurityEvent) string {
+	return evt.Timestamp.Format(time.RFC3339Nano) + "|" + review.Key(evt)
+}
+
+// nextValue steps to the value after current, wrapping to the first
+func nextValue[T comparable](values []T, current T) T {
+	i := slices.Index(values, current)
+	return values[(i+1)%len(values)]
+}
+
+// distinct returns the sorted set of non-empty values of field, led by ""
+func distinct[T ~string](events []agent.SecurityEvent, field func(agent.SecurityEvent) T) []T {
+	values := []T{""}
+	for _, evt := range events {
+		if v := field(evt); v != "" && !slices.Contains(values, v) {
+			values = append(values, v)
+		}
+	}
+	slices.Sort(values[1:])
+	return values
+}
+
+// suppressionSummary lists per-rule suppression counts on one line
+func suppressionSummary(counts []suppress.Count) string {
+	parts := make([]string, 0, len(counts))
+	for _, c := range counts {
+		part := fmt.Sprintf("%s %d", c.Rule, c.Suppressed)
+		switch {
+		case c.Error != "":
+			part += " (invalid)"
+		case c.Expired:
+			part += " (expired)"
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, "  │  ")
+}
+
+// RenderSecurity renders the security review queue
+func RenderSecurity(events []agent.SecurityEvent, decisions *review.Store, suppressed []suppress.Count, f securityFilter, selected, width, height int, s *Styles) string {
+	var b strings.Builder
+	muted := lipgloss.NewStyle().Foreground(s.Theme.Muted)
+
+	b.WriteString(s.SecurityBanner.Width(width).Render(fmt.Sprintf("🛡 Security Review — %d event(s)", len(events))))
+	b.WriteString("\n")
+
+	orAll := func(v string) string {
+		if v == "" {
+			return "all"
+		}
+		return v
+	}
+	decided := "hidden"
+	if f.showDecided {
+		decided = "shown"
+	}
+	b.WriteString(muted.Render(fmt.Sprintf("  Severity: %s  │  Category: %s  │  Agent: %s  │  Reviewed: %s",
+		orAll(string(f.severity)), orAll(string(f.category)), orAll(f.agentName), decided)))
+	b.WriteString("\n")
+	if line := suppressionSummary(suppressed); line != "" {
+		b.WriteString(muted.Render("  Suppressed: "+line) + "\n")
+	}
+	b.WriteString(lipgloss.NewStyle().Foreground(s.Theme.Border).Render(strings.Repeat("─", width)) + "\n")
+
+	if len(events) == 0 {
+		b.WriteString(s.Empty.Width(width).Render("\nNo security events match the filters.\n"))
+		b.WriteString("\n")
+	}
+
+	// Keep the selection on screen: header, filters, suppressions, rule
+	// and help take 6 rows
+	rows := height - 6
+	if rows < 1 {
+		rows = 1
+	}
+	start := 0
+	if selected >= rows {
+		start = selected - rows + 1
+	}
+	end := min(start+rows, len(events))
+
+	for i := start; i < end; i++ {
+		evt := events[i]
+		icon, style := securitySeverityStyle(evt.Severity, s)
+
+		cursor := "  "
+		if i == selected {
+			cursor = s.Logo.Render("▶ ")
+		}
+		status := ""
+		switch st, _ := decisions.Status(evt); st {
+		case review.Acknowledged:
+			status = s.StatusIdle.Render(" [ACK]")
+		case review.FalsePositive:
+			status = muted.Render(" [FALSE POSITIVE]")
+		}
+		if evt.Blocked {
+			status += s.SecurityCritical.Render(" [BLOCKED]")
+		}
+
+		b.WriteString(fmt.Sprintf("%s%s %s %s %s %s %s — %s%s\n",
+			cursor,
+			muted.Render(evt.Timestamp.Format("01-02 15:04:05")),
+			style.Render(icon),
+			style.Render(fmt.Sprintf("[%-8s]", evt.Severity)),
+			muted.Render(string(evt.Category)),
+			lipgloss.NewStyle().Foreground(s.Theme.Secondary).Render(evt.AgentName),
+			style.Render(evt.Description),
+			muted.Italic(true).Render(securityDetailWithLink(evt.Detail, 60)),
+			status,
+		))
+	}
+
+	b.WriteString(s.Help.Render("  a acknowledge  │  f false positive  │  u undo  │  1 severity  │  2 category  │  3 agent  │  h reviewed  │  ESC back"))
+	return b.String()
+}
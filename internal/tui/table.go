@@ -0,0 +1,192 @@
+package tui
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+	"github.com/Rafiki81/libagentmetrics/config"
+	"github.com/Rafiki81/libagentmetrics/monitor"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Dashboard layouts
+const (
+	LayoutCards = "cards"
+	LayoutTable = "table"
+)
+
+// tableColumn is one column of the compact agent table
+type tableColumn struct {
+	key   string
+	title string
+	width int
+	// rank orders which columns survive on narrow terminals, lowest first
+	rank  int
+	right bool
+	value func(a agent.Instance) string
+	// compare orders agents for sorting by this column
+	compare func(a, b agent.Instance) int
+}
+
+// tableColumns lists the columns in display order
+var tableColumns = []tableColumn{
+	{key: "name", title: "NAME", width: 18, rank: 0,
+		value: func(a agent.Instance) string { return a.Info.Name },
+		compare: func(a, b agent.Instance) int {
+			return strings.Compare(strings.ToLower(a.Info.Name), strings.ToLower(b.Info.Name))
+		}},
+	{key: "status", title: "STATUS", width: 9, rank: 1,
+		value:   func(a agent.Instance) string { return a.Status.String() },
+		compare: func(a, b agent.Instance) int { return cmp.Compare(a.Status, b.Status) }},
+	{key: "pid", title: "PID", width: 7, rank: 6, right: true,
+		value:   func(a agent.Instance) string { return fmt.Sprintf("%d", a.PID) },
+		compare: func(a, b agent.Instance) int { return cmp.Compare(a.PID, b.PID) }},
+	{key: "cpu", title: "CPU", width: 6, rank: 2, right: true,
+		value:   func(a agent.Instance) string { return fmt.Sprintf("%.1f%%", a.CPU) },
+		compare: func(a, b agent.Instance) int { return cmp.Compare(a.CPU, b.CPU) }},
+	{key: "mem", title: "MEM", width: 9, rank: 5, right: true,
+		value:   func(a agent.Instance) string { return fmt.Sprintf("%.1f MB", a.Memory) },
+		compare: func(a, b agent.Instance) int { return cmp.Compare(a.Memory, b.Memory) }},
+	{key: "tokens", title: "TOKENS", width: 8, rank: 4, right: true,
+		value:   func(a agent.Instance) string { return monitor.FormatTokenCount(a.Tokens.TotalTokens) },
+		compare: func(a, b agent.Instance) int { return cmp.Compare(a.Tokens.TotalTokens, b.Tokens.TotalTokens) }},
+	{key: "tps", title: "TOK/S", width: 8, rank: 8, right: true,
+		value:   func(a agent.Instance) string { return monitor.FormatTokensPerSec(a.Tokens.TokensPerSec) },
+		compare: func(a, b agent.Instance) int { return cmp.Compare(a.Tokens.TokensPerSec, b.Tokens.TokensPerSec) }},
+	{key: "cost", title: "COST", width: 8, rank: 3, right: true,
+		value:   func(a agent.Instance) string { return monitor.FormatCost(a.Tokens.EstCost) },
+		compare: func(a, b agent.Instance) int { return cmp.Compare(a.Tokens.EstCost, b.Tokens.EstCost) }},
+	{key: "model", title: "MODEL", width: 16, rank: 7,
+		value:   func(a agent.Instance) string { return a.Tokens.LastModel },
+		compare: func(a, b agent.Instance) int { return strings.Compare(a.Tokens.LastModel, b.Tokens.LastModel) }},
+	{key: "uptime", title: "UPTIME", width: 8, rank: 9, right: true,
+		value: func(a agent.Instance) string {
+			if a.Session.Uptime <= 0 {
+				return ""
+			}
+			return monitor.FormatDuration(a.Session.Uptime)
+		},
+		compare: func(a, b agent.Instance) int { return cmp.Compare(a.Session.Uptime, b.Session.Uptime) }},
+	{key: "branch", title: "BRANCH", width: 14, rank: 10,
+		value:   func(a agent.Instance) string { return a.Git.Branch },
+		compare: func(a, b agent.Instance) int { return strings.Compare(a.Git.Branch, b.Git.Branch) }},
+	{key: "dir", title: "DIR", width: 12, rank: 11,
+		value:   func(a agent.Instance) string { return shortenPath(a.WorkDir) },
+		compare: func(a, b agent.Instance) int { return strings.Compare(a.WorkDir, b.WorkDir) }},
+}
+
+// visibleColumns returns the columns that fit in width, dropping the
+// highest ranked first and those the display config hides
+func visibleColumns(width int, disp config.DisplayConfig) []tableColumn {
+	cols := slices.DeleteFunc(slices.Clone(tableColumns), func(c tableColumn) bool {
+		switch c.key {
+		case "tokens", "tps", "model":
+			return !disp.ShowTokens
+		case "cost":
+			return !disp.ShowCost
+		case "uptime":
+			return !disp.ShowSession
+		case "branch":
+			return !disp.ShowGit
+		}
+		return false
+	})
+
+	for len(cols) > 1 && tableWidth(cols) > width {
+		worst := 0
+		for i, c := range cols {
+			if c.rank > cols[worst].rank {
+				worst = i
+			}
+		}
+		cols = slices.Delete(cols, worst, worst+1)
+	}
+
+	// The directory soaks up the spare width, or the name once it's gone
+	grow := slices.IndexFunc(cols, func(c tableColumn) bool { return c.key == "dir" })
+	if grow < 0 {
+		grow = 0
+	}
+	cols[grow].width += max(width-tableWidth(cols), 0)
+	return cols
+}
+
+// tableWidth is the row width of cols: a selection marker plus one space
+// between columns
+func tableWidth(cols []tableColumn) int {
+	w := 2
+	for _, c := range cols {
+		w += c.width + 1
+	}
+	return w
+}
+
+// cell pads or truncates v to the column width
+func cell(c tableColumn, v string) string {
+	if v == "" {
+		v = "-"
+	}
+	v = ansi.Truncate(v, c.width, "…")
+	pad := strings.Repeat(" ", c.width-ansi.StringWidth(v))
+	if c.right {
+		return pad + v
+	}
+	return v + pad
+}
+
+// renderTableHeader renders the column titles, marking the sort column
+func renderTableHeader(cols []tableColumn, sort agentSort, s *Styles) string {
+	parts := make([]string, len(cols))
+	for i, c := range cols {
+		title := c.title
+		if c.key == sort.key {
+			arrow := "▲"
+			if sort.desc {
+				arrow = "▼"
+			}
+			title += arrow
+		}
+		style := lipgloss.NewStyle().Bold(true).Foreground(s.Theme.Secondary)
+		if c.key == sort.key {
+			style = style.Foreground(s.Theme.Primary)
+		}
+		parts[i] = style.Render(cell(c, title))
+	}
+	return "  " + strings.Join(parts, " ")
+}
+
+// renderTableRow renders one agent as a table row
+func renderTableRow(a agent.Instance, cols []tableColumn, selected bool, s *Styles) string {
+	parts := make([]string, len(cols))
+	for i, c := range cols {
+		text := cell(c, c.value(a))
+		style := lipgloss.NewStyle().Foreground(s.Theme.Fg)
+		switch c.key {
+		case "name":
+			style = s.AgentName
+		case "status":
+			style = s.StatusStyle(a.Status.String())
+		case "tokens", "tps":
+			style = s.TokenValue
+		case "cost":
+			style = s.Cost
+		case "model":
+			style = s.TokenSource
+		case "branch":
+			style = s.Git
+		case "dir":
+			style = lipgloss.NewStyle().Foreground(s.Theme.Secondary)
+		}
+		parts[i] = style.Render(text)
+	}
+
+	marker := "  "
+	if selected {
+		marker = lipgloss.NewStyle().Bold(true).Foreground(s.Theme.Primary).Render("▶ ")
+	}
+	return marker + strings.Join(parts, " ")
+}
@@ -0,0 +1,109 @@
+package tui
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/Rafiki81/libagentmetrics/config"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// showAll displays every optional column
+var showAll = config.DisplayConfig{ShowTokens: true, ShowCost: true, ShowSession: true, ShowGit: true}
+
+func columnKeys(cols []tableColumn) []string {
+	keys := make([]string, len(cols))
+	for i, c := range cols {
+		keys[i] = c.key
+	}
+	return keys
+}
+
+func columnWidth(cols []tableColumn, key string) int {
+	i := slices.IndexFunc(cols, func(c tableColumn) bool { return c.key == key })
+	if i < 0 {
+		return -1
+	}
+	return cols[i].width
+}
+
+func TestVisibleColumnsFitWidth(t *testing.T) {
+	full := tableWidth(tableColumns)
+
+	tests := []struct {
+		name      string
+		width     int
+		wantKeys  []string
+		growKey   string
+		growWidth int
+	}{
+		{"wider than the table", full + 20, columnKeys(tableColumns), "dir", 12 + 20},
+		{"exactly the table", full, columnKeys(tableColumns), "dir", 12},
+		// The directory has the highest rank, so the name takes its place
+		{"one cell short", full - 1,
+			[]string{"name", "status", "pid", "cpu", "mem", "tokens", "tps", "cost", "model", "uptime", "branch"},
+			"name", 18 + 12},
+		{"narrow", 40, []string{"name", "status", "cpu"}, "name", 18 + 2},
+		{"narrower than one column", 10, []string{"name"}, "name", 18},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cols := visibleColumns(tt.width, showAll)
+			if got := columnKeys(cols); !slices.Equal(got, tt.wantKeys) {
+				t.Fatalf("expected %v, got %v", tt.wantKeys, got)
+			}
+			if got := columnWidth(cols, tt.growKey); got != tt.growWidth {
+				t.Fatalf("expected %s to be %d wide, got %d", tt.growKey, tt.growWidth, got)
+			}
+			// A lone name column can't shrink below its width
+			if got, want := tableWidth(cols), max(tt.width, 2+18+1); got != want {
+				t.Fatalf("expected the row to fill %d cells, got %d", want, got)
+			}
+		})
+	}
+
+	// Growing a column must not change the shared table
+	if tableWidth(tableColumns) != full {
+		t.Fatal("visibleColumns modified tableColumns")
+	}
+}
+
+func TestVisibleColumnsHonourDisplayConfig(t *testing.T) {
+	cols := visibleColumns(1000, config.DisplayConfig{ShowCost: true})
+	want := []string{"name", "status", "pid", "cpu", "mem", "cost", "dir"}
+	if got := columnKeys(cols); !slices.Equal(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestCellPadsAndTruncates(t *testing.T) {
+	left := tableColumn{width: 6}
+	right := tableColumn{width: 6, right: true}
+
+	tests := []struct {
+		name string
+		col  tableColumn
+		v    string
+		want string
+	}{
+		{"pads left aligned", left, "ab", "ab    "},
+		{"pads right aligned", right, "ab", "    ab"},
+		{"empty shows a dash", left, "", "-     "},
+		{"exact fit", left, "abcdef", "abcdef"},
+		{"truncates", left, "abcdefgh", "abcde…"},
+		// Each of these runes is two cells wide
+		{"truncates wide runes", left, "日本語テキスト", "日本… "},
+		{"right aligns wide runes", right, "日本語", "日本語"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := cell(tt.col, tt.v)
+			if got != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+			if w := ansi.StringWidth(got); w != tt.col.width {
+				t.Fatalf("expected %d cells, got %d", tt.col.width, w)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/rafaelperezbeato/agentmetrics/internal/lifecycle"
+)
+
+// timelinePanelRows is how many transitions the dashboard shows
+const timelinePanelRows = 5
+
+// timelineIcon returns the marker and style for a transition
+func timelineIcon(typ string, s *Styles) (string, lipgloss.Style) {
+	switch typ {
+	case lifecycle.Started:
+		return "▶", s.StatusRunning
+	case lifecycle.Exited:
+		return "■", s.StatusStopped
+	case lifecycle.Status:
+		return "◐", s.StatusIdle
+	case lifecycle.Model:
+		return "⇄", s.TokenValue
+	case lifecycle.Branch:
+		return "⎇", s.MetricValue
+	}
+	return "→", s.MetricValue
+}
+
+// renderTimelineRow renders one transition on one line
+func renderTimelineRow(e lifecycle.Event, width int, s *Styles) string {
+	icon, style := timelineIcon(e.Type, s)
+	line := fmt.Sprintf("  %s %s %s",
+		lipgloss.NewStyle().Foreground(s.Theme.Muted).Render(e.Timestamp.Format("15:04:05")),
+		style.Render(icon),
+		style.Render(e.Describe()),
+	)
+	if e.Type == lifecycle.Started || e.Type == lifecycle.Exited {
+		line += lipgloss.NewStyle().Foreground(s.Theme.Muted).Italic(true).Render("  " + shortenPath(e.WorkDir))
+	}
+	return lipgloss.NewStyle().MaxWidth(width).Render(line)
+}
+
+// renderTimelinePanel renders the latest transitions for the dashboard
+func renderTimelinePanel(events []lifecycle.Event, opts pageOptions, width int, s *Styles) string {
+	var b strings.Builder
+	b.WriteString(s.Header.Width(width).Render(opts.title("timeline", "🕒 Timeline")) + "\n")
+	start := opts.from("timeline", len(events), timelinePanelRows)
+	if start > 0 {
+		b.WriteString(moreLine(start, s))
+	}
+	for _, e := range events[start:] {
+		b.WriteString(renderTimelineRow(e, width, s) + "\n")
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// RenderTimeline renders every retained transition, newest first
+func RenderTimeline(events []lifecycle.Event, width, height int, s *Styles) string {
+	var b strings.Builder
+	b.WriteString(s.Header.Width(width).Render(fmt.Sprintf("🕒 Agent Timeline — %d transition(s)", len(events))))
+	b.WriteString("\n")
+	b.WriteString(lipgloss.NewStyle().Foreground(s.Theme.Border).Render(strings.Repeat("─", width)) + "\n")
+
+	if len(events) == 0 {
+		b.WriteString(s.Empty.Width(width).Render("\nNo transitions yet. Starts, exits, status, model, branch and\nworking directory changes show up here as they happen.\n"))
+		b.WriteString("\n")
+	}
+
+	// Header, rule and help take 3 rows
+	rows := max(height-3, 1)
+	for i := len(events) - 1; i >= 0 && rows > 0; i-- {
+		b.WriteString(renderTimelineRow(events[i], width, s) + "\n")
+		rows--
+	}
+
+	b.WriteString(s.Help.Width(width).Render("  Esc back  │  q quit"))
+	return b.String()
+}
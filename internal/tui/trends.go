@@ -0,0 +1,164 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+	"github.com/Rafiki81/libagentmetrics/monitor"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// sparkLevels are the block characters a sparkline is drawn with
+var sparkLevels = []rune("▁▂▃▄▅▆▇█")
+
+// trendSample is one agent's metrics at one refresh
+type trendSample struct {
+	at           time.Time
+	cpu          float64
+	memory       float64
+	tokensPerSec float64
+	cost         float64
+	loc          float64
+}
+
+// trendStore keeps a rolling window of samples per agent process
+type trendStore struct {
+	window time.Duration
+	series map[int][]trendSample
+}
+
+func newTrendStore(window time.Duration) *trendStore {
+	if window <= 0 {
+		window = 10 * time.Minute
+	}
+	return &trendStore{window: window, series: map[int][]trendSample{}}
+}
+
+// record appends the current metrics and forgets processes that are gone.
+// A snapshot no newer than the last sample is skipped: attached to a
+// daemon, polling can return the same one twice.
+func (t *trendStore) record(agents []agent.Instance, now time.Time) {
+	live := make(map[int]bool, len(agents))
+	for _, a := range agents {
+		live[a.PID] = true
+		samples := t.series[a.PID]
+		if n := len(samples); n > 0 && !now.After(samples[n-1].at) {
+			continue
+		}
+		samples = append(samples, trendSample{
+			at:           now,
+			cpu:          a.CPU,
+			memory:       a.Memory,
+			tokensPerSec: a.Tokens.TokensPerSec,
+			cost:         a.Tokens.EstCost,
+			loc:          float64(a.LOC.Added + a.LOC.Removed),
+		})
+		cut := 0
+		for cut < len(samples) && now.Sub(samples[cut].at) > t.window {
+			cut++
+		}
+		t.series[a.PID] = samples[cut:]
+	}
+	for pid := range t.series {
+		if !live[pid] {
+			delete(t.series, pid)
+		}
+	}
+}
+
+// samples returns the window for one process, oldest first
+func (t *trendStore) samples(pid int) []trendSample {
+	if t == nil {
+		return nil
+	}
+	return t.series[pid]
+}
+
+// resample averages values into at most width buckets
+func resample(values []float64, width int) []float64 {
+	if len(values) <= width || width <= 0 {
+		return values
+	}
+	out := make([]float64, width)
+	for i := range out {
+		from := i * len(values) / width
+		to := (i + 1) * len(values) / width
+		sum := 0.0
+		for _, v := range values[from:to] {
+			sum += v
+		}
+		out[i] = sum / float64(to-from)
+	}
+	return out
+}
+
+// RenderSparkline renders values as a row of block characters scaled
+// between their minimum and maximum
+func (s *Styles) RenderSparkline(values []float64, width int) string {
+	values = resample(values, width)
+	if len(values) == 0 {
+		return s.BarEmpty.Render(strings.Repeat(" ", width))
+	}
+
+	lo, hi := values[0], values[0]
+	for _, v := range values {
+		lo, hi = min(lo, v), max(hi, v)
+	}
+
+	var b strings.Builder
+	for _, v := range values {
+		level := 0
+		if hi > lo {
+			level = int((v - lo) / (hi - lo) * float64(len(sparkLevels)-1))
+		}
+		b.WriteRune(sparkLevels[level])
+	}
+	// Pad so short series line up with full ones
+	pad := strings.Repeat(" ", max(width-len(values), 0))
+	return pad + s.BarFull.Render(b.String())
+}
+
+// renderTrends renders one sparkline per metric over the sample window
+func renderTrends(samples []trendSample, window time.Duration, width int, s *Styles) string {
+	sparkWidth := min(max(width-48, 10), 120)
+
+	rows := []struct {
+		label  string
+		value  func(trendSample) float64
+		format func(float64) string
+	}{
+		{"CPU       ", func(t trendSample) float64 { return t.cpu }, func(v float64) string { return fmt.Sprintf("%.1f%%", v) }},
+		{"Memory    ", func(t trendSample) float64 { return t.memory }, func(v float64) string { return fmt.Sprintf("%.0f MB", v) }},
+		{"Tokens/s  ", func(t trendSample) float64 { return t.tokensPerSec }, monitor.FormatTokensPerSec},
+		{"Cost      ", func(t trendSample) float64 { return t.cost }, monitor.FormatCost},
+		{"LOC ±     ", func(t trendSample) float64 { return t.loc }, func(v float64) string { return fmt.Sprintf("%.0f", v) }},
+	}
+
+	muted := lipgloss.NewStyle().Foreground(s.Theme.Muted)
+	var lines []string
+	for _, r := range rows {
+		values := make([]float64, len(samples))
+		lo, hi := 0.0, 0.0
+		for i, smp := range samples {
+			values[i] = r.value(smp)
+			if i == 0 {
+				lo, hi = values[i], values[i]
+			}
+			lo, hi = min(lo, values[i]), max(hi, values[i])
+		}
+		current := 0.0
+		if len(values) > 0 {
+			current = values[len(values)-1]
+		}
+		lines = append(lines, fmt.Sprintf("%s %s %s %s",
+			s.MetricLabel.Render(r.label),
+			s.RenderSparkline(values, sparkWidth),
+			s.MetricValue.Render(fmt.Sprintf("%-10s", r.format(current))),
+			muted.Render(fmt.Sprintf("min %s  max %s", r.format(lo), r.format(hi))),
+		))
+	}
+	lines = append(lines, muted.Render(fmt.Sprintf("%d sample(s) over the last %s", len(samples), window)))
+	return strings.Join(lines, "\n")
+}
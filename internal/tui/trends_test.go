@@ -0,0 +1,88 @@
+package tui
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Rafiki81/libagentmetrics/agent"
+	"github.com/Rafiki81/libagentmetrics/config"
+)
+
+func TestTrendStoreKeepsWindowAndSkipsRepeatedSnapshots(t *testing.T) {
+	store := newTrendStore(time.Minute)
+	start := time.Date(2026, 3, 2, 10, 0, 0, 0, time.UTC)
+	claude := agent.Instance{PID: 1, CPU: 10}
+	aider := agent.Instance{PID: 2}
+
+	store.record([]agent.Instance{claude, aider}, start)
+	store.record([]agent.Instance{claude, aider}, start.Add(30*time.Second))
+	// The same daemon snapshot polled again, then an older one
+	store.record([]agent.Instance{claude, aider}, start.Add(30*time.Second))
+	store.record([]agent.Instance{claude, aider}, start.Add(10*time.Second))
+	if got := len(store.samples(1)); got != 2 {
+		t.Fatalf("expected 2 samples, got %d", got)
+	}
+
+	// Aider exits and the first sample falls out of the window
+	store.record([]agent.Instance{claude}, start.Add(90*time.Second))
+	samples := store.samples(1)
+	if len(samples) != 2 || !samples[0].at.Equal(start.Add(30*time.Second)) {
+		t.Fatalf("expected the samples from 30s and 90s, got %+v", samples)
+	}
+	if store.samples(2) != nil {
+		t.Fatal("an exited process should be forgotten")
+	}
+}
+
+func TestResample(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		width  int
+		want   []float64
+	}{
+		{"fits", []float64{1, 2, 3}, 5, []float64{1, 2, 3}},
+		{"averages buckets", []float64{1, 3, 5, 7}, 2, []float64{2, 6}},
+		{"uneven buckets", []float64{1, 2, 3, 4, 5}, 2, []float64{1.5, 4}},
+		{"no width", []float64{1, 2}, 0, []float64{1, 2}},
+		{"empty", nil, 4, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resample(tt.values, tt.width); !slices.Equal(got, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRenderSparklineFlatAndShortSeries(t *testing.T) {
+	s := NewStyles(config.DefaultConfig().Theme)
+
+	tests := []struct {
+		name   string
+		values []float64
+		want   string
+	}{
+		{"all zero", []float64{0, 0, 0}, "▁▁▁"},
+		{"single value", []float64{42}, "▁"},
+		{"rising", []float64{0, 7}, "▁█"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := s.RenderSparkline(tt.values, 8)
+			if !strings.Contains(got, tt.want) {
+				t.Fatalf("expected %q in %q", tt.want, got)
+			}
+			if !strings.HasPrefix(got, strings.Repeat(" ", 8-len(tt.values))) {
+				t.Fatalf("expected a short series to be padded, got %q", got)
+			}
+		})
+	}
+
+	if got := s.RenderSparkline(nil, 4); !strings.Contains(got, "    ") {
+		t.Fatalf("expected an empty series to render blank, got %q", got)
+	}
+}
@@ -0,0 +1,123 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// span is the first line and height of an item on a page
+type span struct {
+	top    int
+	height int
+}
+
+// page is rendered content that remembers which line each section and
+// item starts on, so the viewport can scroll to them
+type page struct {
+	b        strings.Builder
+	lines    int
+	sections []string
+	starts   map[string]int
+	items    map[int]span
+}
+
+func newPage() *page {
+	return &page{starts: map[string]int{}, items: map[int]span{}}
+}
+
+// WriteString appends rendered text
+func (p *page) WriteString(s string) {
+	p.b.WriteString(s)
+	p.lines += strings.Count(s, "\n")
+}
+
+// section marks the current line as the start of a focusable section
+func (p *page) section(name string) {
+	if _, ok := p.starts[name]; !ok {
+		p.sections = append(p.sections, name)
+	}
+	p.starts[name] = p.lines
+}
+
+// item writes a rendered block (e.g. an agent card) and records its span
+func (p *page) item(i int, rendered string) {
+	p.items[i] = span{top: p.lines, height: strings.Count(rendered, "\n") + 1}
+	p.WriteString(rendered + "\n")
+}
+
+// String returns the full content
+func (p *page) String() string {
+	return p.b.String()
+}
+
+// Lines returns the number of rendered lines
+func (p *page) Lines() int {
+	return p.lines + 1
+}
+
+// nextSection returns the section after (or before, with step -1) the
+// focused one, wrapping around; "" when the page has none
+func (p *page) nextSection(focus string, step int) string {
+	if len(p.sections) == 0 {
+		return ""
+	}
+	i := slices.Index(p.sections, focus)
+	if i < 0 {
+		if step < 0 {
+			return p.sections[len(p.sections)-1]
+		}
+		return p.sections[0]
+	}
+	n := len(p.sections)
+	return p.sections[((i+step)%n+n)%n]
+}
+
+// viewport is a scroll position over a page
+type viewport struct {
+	offset int
+}
+
+// clamp keeps the offset within the content for a window of height rows
+func (v viewport) clamp(lines, height int) viewport {
+	v.offset = min(v.offset, max(lines-height, 0))
+	v.offset = max(v.offset, 0)
+	return v
+}
+
+// scroll moves by delta lines
+func (v viewport) scroll(delta, lines, height int) viewport {
+	v.offset += delta
+	return v.clamp(lines, height)
+}
+
+// show scrolls the least needed to bring s fully into view, favouring its
+// top when it is taller than the window
+func (v viewport) show(s span, lines, height int) viewport {
+	if s.top+s.height > v.offset+height {
+		v.offset = s.top + s.height - height
+	}
+	if s.top < v.offset {
+		v.offset = s.top
+	}
+	return v.clamp(lines, height)
+}
+
+// window returns the visible rows of p
+func (v viewport) window(p *page, height int) string {
+	v = v.clamp(p.Lines(), height)
+	lines := strings.Split(p.String(), "\n")
+	end := min(v.offset+height, len(lines))
+	return strings.Join(lines[v.offset:end], "\n")
+}
+
+// position describes the visible range, e.g. "12-40/120"; "" when all of
+// the page fits
+func (v viewport) position(p *page, height int) string {
+	lines := p.Lines()
+	if lines <= height {
+		return ""
+	}
+	v = v.clamp(lines, height)
+	return fmt.Sprintf("%d-%d/%d", v.offset+1, min(v.offset+height, lines), lines)
+}
@@ -0,0 +1,142 @@
+package tui
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// numberedPage renders n lines, "0" to "n-1"
+func numberedPage(n int) *page {
+	p := newPage()
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = strconv.Itoa(i)
+	}
+	p.WriteString(strings.Join(lines, "\n"))
+	return p
+}
+
+func TestViewportClampsScrolling(t *testing.T) {
+	tests := []struct {
+		name   string
+		offset int
+		delta  int
+		lines  int
+		height int
+		want   int
+	}{
+		{"within content", 0, 5, 30, 10, 5},
+		{"past the end", 15, 10, 30, 10, 20},
+		{"before the top", 3, -10, 30, 10, 0},
+		{"shorter than the window", 0, 5, 4, 10, 0},
+		{"exactly the window", 0, 1, 10, 10, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := viewport{offset: tt.offset}.scroll(tt.delta, tt.lines, tt.height)
+			if got.offset != tt.want {
+				t.Fatalf("expected offset %d, got %d", tt.want, got.offset)
+			}
+		})
+	}
+}
+
+func TestViewportWindowAndPosition(t *testing.T) {
+	p := numberedPage(30)
+
+	// An offset left past the end by a shrinking page shows the last rows
+	v := viewport{offset: 50}
+	if got := strings.Split(v.window(p, 10), "\n"); len(got) != 10 || got[9] != "29" {
+		t.Fatalf("expected the last 10 lines, got %q", got)
+	}
+	if got := v.position(p, 10); got != "21-30/30" {
+		t.Fatalf("expected 21-30/30, got %q", got)
+	}
+
+	short := numberedPage(4)
+	if got := (viewport{offset: 3}).window(short, 10); got != short.String() {
+		t.Fatalf("expected all of a short page, got %q", got)
+	}
+	if got := (viewport{}).position(short, 10); got != "" {
+		t.Fatalf("a page that fits has no position, got %q", got)
+	}
+}
+
+func TestViewportShowBringsSpanIntoView(t *testing.T) {
+	tests := []struct {
+		name   string
+		offset int
+		span   span
+		want   int
+	}{
+		{"already visible", 5, span{top: 7, height: 3}, 5},
+		{"below", 0, span{top: 12, height: 3}, 5},
+		{"above", 10, span{top: 4, height: 2}, 4},
+		{"taller than the window", 0, span{top: 8, height: 15}, 8},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (viewport{offset: tt.offset}).show(tt.span, 40, 10); got.offset != tt.want {
+				t.Fatalf("expected offset %d, got %d", tt.want, got.offset)
+			}
+		})
+	}
+}
+
+func TestPageSectionFocusCycles(t *testing.T) {
+	p := newPage()
+	p.section("agents")
+	p.WriteString("agents\n\n")
+	p.section("alerts")
+	p.WriteString("alerts\n")
+	p.section("security")
+	p.WriteString("security\n")
+
+	if p.starts["alerts"] != 2 || p.starts["security"] != 3 {
+		t.Fatalf("unexpected section starts %v", p.starts)
+	}
+
+	tests := []struct {
+		focus string
+		step  int
+		want  string
+	}{
+		{"", 1, "agents"},
+		{"", -1, "security"},
+		{"agents", 1, "alerts"},
+		{"security", 1, "agents"},
+		{"agents", -1, "security"},
+		{"gone", 1, "agents"},
+	}
+	for _, tt := range tests {
+		if got := p.nextSection(tt.focus, tt.step); got != tt.want {
+			t.Fatalf("from %q by %d: expected %q, got %q", tt.focus, tt.step, tt.want, got)
+		}
+	}
+
+	if got := newPage().nextSection("", 1); got != "" {
+		t.Fatalf("a page without sections has no focus, got %q", got)
+	}
+}
+
+func TestPageOptionsShowAll(t *testing.T) {
+	opts := pageOptions{focus: "alerts"}
+	if got := opts.from("alerts", 12, 5); got != 7 {
+		t.Fatalf("expected the last 5 of 12, from 7, got %d", got)
+	}
+	if got := opts.from("alerts", 3, 5); got != 0 {
+		t.Fatalf("a short list shows everything, got %d", got)
+	}
+
+	opts.expanded = map[string]bool{"alerts": true}
+	if got := opts.from("alerts", 12, 5); got != 0 {
+		t.Fatalf("an expanded section shows everything, got %d", got)
+	}
+	if got := opts.from("security", 12, 5); got != 7 {
+		t.Fatalf("other sections stay capped, got %d", got)
+	}
+	if got := opts.title("alerts", "Alerts"); got != "▸ Alerts" {
+		t.Fatalf("expected the focus marker, got %q", got)
+	}
+}